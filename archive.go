@@ -0,0 +1,292 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Desteklenen arşiv biçimleri ('archive.format').
+const (
+	ArchiveAuto  = "auto" // Biçim dosyanın ilk baytlarından anlaşılır
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+	ArchiveTarZs = "tar.zst"
+	ArchiveZip   = "zip"
+)
+
+const (
+	defaultArchiveMaxBytes = 16 << 30 // Açılmış içeriğin toplam boyut sınırı (16 GiB)
+	defaultArchiveMaxFiles = 100000   // Arşivdeki dosya sayısı sınırı
+)
+
+// ErrArchiveInvalid, arşiv açılamadığında veya güvenlik kurallarını
+// (dizin dışına yazma, boyut/dosya sayısı sınırı, bağlantılar) ihlal ettiğinde
+// döndürülür. Böyle bir sürüm karantinaya alınır.
+var ErrArchiveInvalid = errors.New("geçersiz arşiv")
+
+// ArchiveConfig, arşiv olarak yayınlanan modellerin nasıl açılacağını belirler.
+type ArchiveConfig struct {
+	Format   string `json:"format"`    // "", "auto", "tar", "tar.gz", "tar.zst" veya "zip"
+	MaxBytes int64  `json:"max_bytes"` // Açılmış içeriğin toplam boyut sınırı (varsayılan: 16 GiB)
+	MaxFiles int    `json:"max_files"` // Dosya sayısı sınırı (varsayılan: 100000)
+}
+
+// detectArchiveFormat, dosyanın ilk baytlarına bakarak arşiv biçimini bulur.
+func detectArchiveFormat(archivePath string) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return ArchiveTarGz, nil
+	case bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return ArchiveTarZs, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return ArchiveZip, nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return ArchiveTar, nil
+	}
+	return "", fmt.Errorf("%w: arşiv biçimi anlaşılamadı", ErrArchiveInvalid)
+}
+
+// extractArchive, 'archivePath'teki arşivi 'destDir' dizinine açar. Arşiv önce
+// '<destDir>.tmp' altına açılır ve tamamı başarıyla yazıldığında tek bir rename
+// ile yerine taşınır. Dizin dışına çıkan yollar, bağlantılar (symlink/hardlink),
+// özel dosyalar ve sınırları aşan arşivler reddedilir. 'reserve' verilmişse her
+// dosyanın (başlıkta bildirilen) boyutuyla yazmadan önce çağrılır; hata
+// döndürürse açma durdurulur ve hata olduğu gibi döndürülür.
+func extractArchive(archivePath, destDir string, cfg ArchiveConfig, reserve func(size int64) error) error {
+	format := cfg.Format
+	if format == ArchiveAuto {
+		var err error
+		if format, err = detectArchiveFormat(archivePath); err != nil {
+			return err
+		}
+	}
+
+	tmpDir := destDir + tmpDirSuffix
+	os.RemoveAll(tmpDir) // Önceki yarım denemeden kalanlar.
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return fmt.Errorf("arşiv dizini oluşturulamadı (%s): %w", tmpDir, err)
+	}
+
+	x := &extractor{root: tmpDir, maxBytes: cfg.MaxBytes, maxFiles: cfg.MaxFiles, reserve: reserve}
+	if x.maxBytes <= 0 {
+		x.maxBytes = defaultArchiveMaxBytes
+	}
+	if x.maxFiles <= 0 {
+		x.maxFiles = defaultArchiveMaxFiles
+	}
+
+	var err error
+	switch format {
+	case ArchiveZip:
+		err = x.extractZip(archivePath)
+	case ArchiveTar, ArchiveTarGz, ArchiveTarZs:
+		err = x.extractTarFile(archivePath, format)
+	default:
+		err = fmt.Errorf("desteklenmeyen arşiv biçimi: '%s'", format)
+	}
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+
+	if err := os.RemoveAll(destDir); err != nil {
+		return fmt.Errorf("eski model dizini silinemedi (%s): %w", destDir, err)
+	}
+	if err := os.Rename(tmpDir, destDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("model dizini yerine taşınamadı (%s): %w", destDir, err)
+	}
+	syncDir(filepath.Dir(destDir))
+	return nil
+}
+
+// extractor, bir arşivin açılması sırasında sınırları takip eder.
+type extractor struct {
+	root     string
+	maxBytes int64
+	maxFiles int
+	reserve  func(size int64) error
+	written  int64
+	files    int
+}
+
+// target, arşivdeki bir girdi adını açma dizini içindeki güvenli bir yola çevirir.
+func (x *extractor) target(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: güvensiz yol '%s'", ErrArchiveInvalid, name)
+	}
+	if clean == "." {
+		return x.root, nil
+	}
+	return filepath.Join(x.root, filepath.FromSlash(clean)), nil
+}
+
+// writeFile, bir girdinin içeriğini sınırları aşmadan diske yazar. 'size',
+// girdinin başlıkta bildirilen boyutudur; tar ve zip okuyucuları içeriğin bu
+// boyuttan farklı olmasına izin vermez.
+func (x *extractor) writeFile(name string, r io.Reader, size int64, executable bool) error {
+	dest, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	x.files++
+	if x.files > x.maxFiles {
+		return fmt.Errorf("%w: dosya sayısı sınırı (%d) aşıldı", ErrArchiveInvalid, x.maxFiles)
+	}
+	if size < 0 || size > x.maxBytes-x.written {
+		return fmt.Errorf("%w: açılmış boyut sınırı (%d bayt) aşıldı", ErrArchiveInvalid, x.maxBytes)
+	}
+	if x.reserve != nil {
+		if err := x.reserve(size); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0o644)
+	if executable {
+		mode = 0o755
+	}
+	// O_EXCL: aynı yolun arşivde iki kez bulunması (ve ilkinin üzerine yazılması) reddedilir.
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w: '%s' birden fazla kez bulunuyor", ErrArchiveInvalid, name)
+		}
+		return err
+	}
+	defer f.Close()
+
+	// Sınırın bir bayt fazlasını okumaya çalış; okunabiliyorsa arşiv sınırı aşıyor demektir.
+	remaining := x.maxBytes - x.written
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	x.written += n
+	if err != nil {
+		return fmt.Errorf("%w: '%s' açılamadı: %v", ErrArchiveInvalid, name, err)
+	}
+	if n > remaining {
+		return fmt.Errorf("%w: açılmış boyut sınırı (%d bayt) aşıldı", ErrArchiveInvalid, x.maxBytes)
+	}
+	return f.Sync()
+}
+
+// extractTarFile, (sıkıştırılmış olabilen) bir tar arşivini açar.
+func (x *extractor) extractTarFile(archivePath, format string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	switch format {
+	case ArchiveTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+		}
+		defer gz.Close()
+		r = gz
+	case ArchiveTarZs:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			dir, err := x.target(hdr.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := x.writeFile(hdr.Name, tr, hdr.Size, hdr.Mode&0o111 != 0); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			// PAX genel başlığı; içerik değil.
+		default:
+			return fmt.Errorf("%w: '%s' desteklenmeyen girdi türü (bağlantı veya özel dosya)", ErrArchiveInvalid, hdr.Name)
+		}
+	}
+}
+
+// extractZip, bir zip arşivini açar.
+func (x *extractor) extractZip(archivePath string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			dir, err := x.target(zf.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if zf.UncompressedSize64 > math.MaxInt64 {
+				return fmt.Errorf("%w: '%s' için bildirilen boyut geçersiz", ErrArchiveInvalid, zf.Name)
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrArchiveInvalid, err)
+			}
+			err = x.writeFile(zf.Name, rc, int64(zf.UncompressedSize64), mode&0o111 != 0)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: '%s' desteklenmeyen girdi türü (bağlantı veya özel dosya)", ErrArchiveInvalid, zf.Name)
+		}
+	}
+	return nil
+}
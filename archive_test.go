@@ -0,0 +1,232 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	link    string // Doluysa girdi bir sembolik bağdır
+}
+
+func makeTar(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.link != "" {
+			hdr = &tar.Header{Name: e.name, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.content))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func makeArchive(t *testing.T, format string, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	switch format {
+	case ArchiveTarGz:
+		gw := gzip.NewWriter(&buf)
+		gw.Write(makeTar(t, entries))
+		gw.Close()
+	case ArchiveTarZs:
+		zw, _ := zstd.NewWriter(&buf)
+		zw.Write(makeTar(t, entries))
+		zw.Close()
+	case ArchiveZip:
+		zw := zip.NewWriter(&buf)
+		for _, e := range entries {
+			w, _ := zw.Create(e.name)
+			w.Write([]byte(e.content))
+		}
+		zw.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "model.onnx", content: "ağırlıklar"},
+		{name: "tokenizer/vocab.txt", content: "a\nb\n"},
+	}
+	for _, format := range []string{ArchiveTarGz, ArchiveTarZs, ArchiveZip} {
+		t.Run(format, func(t *testing.T) {
+			root := t.TempDir()
+			archivePath := filepath.Join(root, "model.bin")
+			os.WriteFile(archivePath, makeArchive(t, format, entries), 0o644)
+
+			dest := filepath.Join(root, "model")
+			// Biçim otomatik algılanmalı.
+			if err := extractArchive(archivePath, dest, ArchiveConfig{Format: ArchiveAuto}, nil); err != nil {
+				t.Fatalf("extractArchive() hata döndürdü: %v", err)
+			}
+			for _, e := range entries {
+				got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(e.name)))
+				if err != nil || string(got) != e.content {
+					t.Errorf("'%s' içeriği '%s' olmalıydı, alınan '%s' (%v)", e.name, e.content, got, err)
+				}
+			}
+			if _, err := os.Stat(dest + tmpDirSuffix); !os.IsNotExist(err) {
+				t.Error("Geçici dizin kalmamalıydı")
+			}
+		})
+	}
+}
+
+func TestExtractArchive_Unsafe(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		entries []archiveEntry
+		cfg     ArchiveConfig
+	}{
+		{"dizin dışına çıkan yol", ArchiveTarGz, []archiveEntry{{name: "../../etc/cron.d/evil", content: "x"}}, ArchiveConfig{}},
+		{"mutlak yol", ArchiveTarGz, []archiveEntry{{name: "/etc/evil", content: "x"}}, ArchiveConfig{}},
+		{"zip dizin dışı", ArchiveZip, []archiveEntry{{name: "a/../../evil", content: "x"}}, ArchiveConfig{}},
+		{"sembolik bağ", ArchiveTarGz, []archiveEntry{{name: "link", link: "/etc/passwd"}}, ArchiveConfig{}},
+		{"tekrar eden yol", ArchiveTarGz, []archiveEntry{{name: "a", content: "1"}, {name: "./a", content: "2"}}, ArchiveConfig{}},
+		{"boyut sınırı", ArchiveTarZs, []archiveEntry{{name: "a", content: "0123456789"}}, ArchiveConfig{MaxBytes: 5}},
+		{"dosya sayısı sınırı", ArchiveZip, []archiveEntry{{name: "a", content: "1"}, {name: "b", content: "2"}}, ArchiveConfig{MaxFiles: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			archivePath := filepath.Join(root, "model.bin")
+			os.WriteFile(archivePath, makeArchive(t, tt.format, tt.entries), 0o644)
+
+			tt.cfg.Format = tt.format
+			dest := filepath.Join(root, "model")
+			err := extractArchive(archivePath, dest, tt.cfg, nil)
+			if !errors.Is(err, ErrArchiveInvalid) {
+				t.Fatalf("ErrArchiveInvalid bekleniyordu, alınan: %v", err)
+			}
+			for _, dir := range []string{dest, dest + tmpDirSuffix} {
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("'%s' dizini kalmamalıydı", dir)
+				}
+			}
+		})
+	}
+}
+
+// TestExtractArchive_Reserve, her dosyanın yazılmadan önce bildirilen boyutuyla
+// 'reserve' üzerinden denetlendiğini ve reddedilen arşivden geriye dosya
+// kalmadığını test eder.
+func TestExtractArchive_Reserve(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "a", content: "0123"},
+		{name: "b", content: "456789"},
+	}
+	for _, format := range []string{ArchiveTarGz, ArchiveZip} {
+		t.Run(format, func(t *testing.T) {
+			root := t.TempDir()
+			archivePath := filepath.Join(root, "model.bin")
+			os.WriteFile(archivePath, makeArchive(t, format, entries), 0o644)
+
+			var reserved []int64
+			reserve := func(size int64) error {
+				reserved = append(reserved, size)
+				if len(reserved) == 2 {
+					return ErrInsufficientSpace
+				}
+				return nil
+			}
+			dest := filepath.Join(root, "model")
+			err := extractArchive(archivePath, dest, ArchiveConfig{Format: format}, reserve)
+			if !errors.Is(err, ErrInsufficientSpace) || errors.Is(err, ErrArchiveInvalid) {
+				t.Fatalf("ErrInsufficientSpace (ErrArchiveInvalid değil) bekleniyordu, alınan: %v", err)
+			}
+			if len(reserved) != 2 || reserved[0] != 4 || reserved[1] != 6 {
+				t.Errorf("Dosya boyutları [4 6] ile denetlenmeliydi, alınan: %v", reserved)
+			}
+			for _, dir := range []string{dest, dest + tmpDirSuffix} {
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("'%s' dizini kalmamalıydı", dir)
+				}
+			}
+		})
+	}
+}
+
+// TestPoller_ArchiveTooLarge, arşivin kendisi küçük olsa bile açılmış içeriği
+// 'max_model_bytes' sınırını aşan bir sürümün reddedildiğini ve karantinaya
+// alındığını test eder.
+func TestPoller_ArchiveTooLarge(t *testing.T) {
+	root := t.TempDir()
+	archive := makeArchive(t, ArchiveTarGz, []archiveEntry{
+		{name: "model.onnx", content: strings.Repeat("0", 3000)},
+		{name: "vocab.txt", content: strings.Repeat("1", 3000)},
+	})
+	mockCfg := &Config{DataDir: root, S3Key: "prod/model.tar.gz", DeployScriptPath: "deploy.sh",
+		Archive: ArchiveConfig{Format: ArchiveAuto}, Disk: DiskConfig{MaxModelBytes: 5000}}
+	if int64(len(archive)) >= mockCfg.Disk.MaxModelBytes {
+		t.Fatalf("Test arşivi sınırdan küçük olmalıydı (%d bayt)", len(archive))
+	}
+	mockS3 := &MockS3Client{EtagToReturn: "v2", Objects: map[string][]byte{"prod/model.tar.gz": archive}}
+	mockDeploy := &MockDeployer{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(root, "active_model_link"))
+	if err := p.RunOnce(); !errors.Is(err, ErrModelTooLarge) {
+		t.Fatalf("ErrModelTooLarge bekleniyordu, alınan: %v", err)
+	}
+	if len(mockDeploy.Calls) != 0 {
+		t.Errorf("Sınırı aşan model test edilmemeliydi: %v", mockDeploy.Calls)
+	}
+	if !p.isQuarantined("v2") {
+		t.Error("Sınırı aşan revizyon karantinaya alınmalıydı")
+	}
+	if _, err := os.Stat(filepath.Join(root, modelsSubdir, "model-v2")); !os.IsNotExist(err) {
+		t.Error("Yarım açılmış model dizini kalmamalıydı")
+	}
+}
+
+// TestPoller_Archive, arşiv olarak yayınlanan modelin sürüme özel bir dizine
+// açıldığını ve test script'ine ve aktif bağa bu dizinin verildiğini test eder.
+func TestPoller_Archive(t *testing.T) {
+	root := t.TempDir()
+	mockCfg := &Config{DataDir: root, S3Key: "prod/model.tar.gz", DeployScriptPath: "deploy.sh", Archive: ArchiveConfig{Format: ArchiveAuto}}
+	mockS3 := &MockS3Client{
+		EtagToReturn: "v2",
+		Objects:      map[string][]byte{"prod/model.tar.gz": makeArchive(t, ArchiveTarGz, []archiveEntry{{name: "model.onnx", content: "x"}})},
+	}
+	mockLink := &MockLinker{}
+	mockDeploy := &MockDeployer{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, mockStore, &MockHealthProber{}, filepath.Join(root, "active_model_link"))
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	modelDir := filepath.Join(root, modelsSubdir, "model-v2")
+	if _, err := os.Stat(filepath.Join(modelDir, "model.onnx")); err != nil {
+		t.Errorf("Arşiv açılmalıydı: %v", err)
+	}
+	if _, err := os.Stat(modelDir + ".bin"); !os.IsNotExist(err) {
+		t.Error("Açılan arşiv dosyası silinmeliydi")
+	}
+	if mockDeploy.Calls[0] != "deploy.sh --test "+modelDir {
+		t.Errorf("Test script'i model diziniyle çağrılmalıydı: %v", mockDeploy.Calls)
+	}
+	if mockStore.State.ActiveModelPath != modelDir {
+		t.Errorf("Aktif model yolu '%s' olmalıydı, ancak '%s'", modelDir, mockStore.State.ActiveModelPath)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+)
+
+// SourceAzure, modelin Azure Blob Storage'dan alındığı kaynaktır (Config.Source).
+const SourceAzure = "azure"
+
+// defaultAzureBlockSize, modelin kaç baytlık bloklar halinde indirileceğidir.
+const defaultAzureBlockSize = 8 << 20
+
+// AzureSourceConfig, "azure" kaynağının hesap, uç nokta ve kimlik bilgileridir.
+// Container adı 's3_bucket', blob adı 's3_key' (veya 's3_prefix') ile verilir.
+// Hesap anahtarı ve SAS token'ındaki $DEĞİŞKEN ifadeleri ortam değişkenleriyle
+// değiştirilir.
+type AzureSourceConfig struct {
+	AccountName    string `json:"account_name"`     // Depolama hesabı (Azurite için "devstoreaccount1")
+	AccountKey     string `json:"account_key"`      // Shared key (örn: "$AZURE_STORAGE_KEY")
+	SASToken       string `json:"sas_token"`        // SAS token'ı ('?' olmadan, örn: "$AZURE_SAS_TOKEN")
+	Endpoint       string `json:"endpoint"`         // Blob servis adresi (varsayılan: "https://<hesap>.blob.core.windows.net/")
+	BlockSizeBytes int64  `json:"block_size_bytes"` // İndirme blok boyutu (varsayılan: 8 MiB)
+}
+
+// String, yapılandırma loglanırken hesap anahtarını ve SAS token'ını gizler.
+func (c AzureSourceConfig) String() string {
+	if c.AccountName == "" && c.Endpoint == "" {
+		return "{}"
+	}
+	return fmt.Sprintf("{AccountName:%s Endpoint:%s SharedKey:%t SAS:%t BlockSizeBytes:%d}",
+		c.AccountName, c.Endpoint, c.AccountKey != "", c.SASToken != "", c.BlockSizeBytes)
+}
+
+// RealAzureClient, S3Client arayüzünün Azure Blob Storage implementasyonudur.
+// Revizyon, blob'un ETag'i (versiyonlama açıksa VersionID) ile izlenir. Model,
+// her biri If-Match ile aynı ETag'e sabitlenmiş ranged isteklerle blok blok indirilir.
+type RealAzureClient struct {
+	client     *azblob.Client
+	blockSize  int64
+	stagingDir string // İndirmelerin tamamlanana kadar yazıldığı dizin
+}
+
+// NewRealAzureClient, 'cfg.Azure' ayarlarıyla yeni bir RealAzureClient oluşturur.
+// Hesap anahtarı verilmişse shared key, SAS token'ı verilmişse SAS ile, ikisi de
+// yoksa anonim (herkese açık container) erişim kullanılır.
+func NewRealAzureClient(cfg *Config, stagingDir string) (*RealAzureClient, error) {
+	az := cfg.Azure
+	endpoint := az.Endpoint
+	if endpoint == "" {
+		if az.AccountName == "" {
+			return nil, fmt.Errorf("azure kaynağı için 'azure.account_name' veya 'azure.endpoint' gerekli")
+		}
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", az.AccountName)
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+
+	var client *azblob.Client
+	var err error
+	accountKey, sas := os.ExpandEnv(az.AccountKey), strings.TrimPrefix(os.ExpandEnv(az.SASToken), "?")
+	switch {
+	case accountKey != "":
+		cred, credErr := azblob.NewSharedKeyCredential(az.AccountName, accountKey)
+		if credErr != nil {
+			return nil, fmt.Errorf("azure shared key geçersiz: %w", credErr)
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+	case sas != "":
+		client, err = azblob.NewClientWithNoCredential(endpoint+"?"+sas, nil)
+	default:
+		client, err = azblob.NewClientWithNoCredential(endpoint, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("azure istemcisi oluşturulamadı: %w", err)
+	}
+
+	blockSize := az.BlockSizeBytes
+	if blockSize <= 0 {
+		blockSize = defaultAzureBlockSize
+	}
+	return &RealAzureClient{client: client, blockSize: blockSize, stagingDir: stagingDir}, nil
+}
+
+// blobClient, 'bucket' container'ındaki 'key' blob'u için (ve versionID
+// verilmişse o sürüme sabitlenmiş) bir istemci döndürür.
+func (a *RealAzureClient) blobClient(bucket, key, versionID string) (*blob.Client, error) {
+	client := a.client.ServiceClient().NewContainerClient(bucket).NewBlobClient(key)
+	if versionID == "" {
+		return client, nil
+	}
+	return client.WithVersionID(versionID)
+}
+
+// HeadObject, blob'un (versionID verilmişse o sürümün) özelliklerini okur.
+// Revizyon, ETag ve varsa VersionID'dir; metadata anahtarları küçük harfe çevrilir.
+func (a *RealAzureClient) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	client, err := a.blobClient(bucket, key, versionID)
+	if err != nil {
+		return ObjectVersion{}, err
+	}
+	props, err := client.GetProperties(context.TODO(), nil)
+	if err != nil {
+		return ObjectVersion{}, azureError("GetProperties", bucket, key, err)
+	}
+
+	obj := ObjectVersion{
+		Key:          key,
+		ETag:         azureETag(props.ETag),
+		VersionID:    deref(props.VersionID),
+		LastModified: deref(props.LastModified),
+		Size:         deref(props.ContentLength),
+	}
+	if versionID != "" {
+		obj.VersionID = versionID
+	}
+	if len(props.Metadata) > 0 {
+		obj.Metadata = make(map[string]string, len(props.Metadata))
+		for name, value := range props.Metadata {
+			// Azure, metadata adlarını yüklendikleri harf büyüklüğüyle döndürür.
+			obj.Metadata[strings.ToLower(name)] = deref(value)
+		}
+	}
+	return obj, nil
+}
+
+// ExpectedSHA256, revizyon için yayınlanmış SHA-256 özetini önce 'sha256'
+// metadata'sından, sonra (revizyon bir VersionID'ye sabitlenmemişse)
+// '<key>.sha256' yan blob'undan okur.
+func (a *RealAzureClient) ExpectedSHA256(bucket string, obj ObjectVersion) (string, error) {
+	return lookupSHA256(obj, func(key string) ([]byte, error) {
+		return a.FetchObject(bucket, key)
+	})
+}
+
+// ListObjects, container'da 'prefix' ile başlayan blob'ları (prefix izleme modu için) listeler.
+func (a *RealAzureClient) ListObjects(bucket, prefix string) ([]ObjectVersion, error) {
+	var objects []ObjectVersion
+	pager := a.client.ServiceClient().NewContainerClient(bucket).NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix: &prefix,
+	})
+	for pager.More() {
+		page, err := pager.NextPage(context.TODO())
+		if err != nil {
+			return nil, azureError("ListBlobs", bucket, prefix, err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			obj := ObjectVersion{Key: deref(item.Name)}
+			if item.Properties != nil {
+				obj.ETag = azureETag(item.Properties.ETag)
+				obj.LastModified = deref(item.Properties.LastModified)
+				obj.Size = deref(item.Properties.ContentLength)
+			}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// FetchObject, küçük bir blob'un (imza, özet, manifesto) en son sürümünü belleğe okur.
+func (a *RealAzureClient) FetchObject(bucket, key string) ([]byte, error) {
+	client, err := a.blobClient(bucket, key, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DownloadStream(context.TODO(), nil)
+	if err != nil {
+		return nil, azureError("Download", bucket, key, err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil, fmt.Errorf("azure blob okunamadı (%s/%s): %w", bucket, key, err)
+	}
+	return content, nil
+}
+
+// DownloadObject, blob'u S3 istemcisiyle aynı şekilde önce staging'e indirir,
+// özetini akış sırasında hesaplar ve tamamlandığında hedefe taşır. Bağlantı
+// koparsa bir sonraki denemede kaldığı bloktan devam edilir.
+func (a *RealAzureClient) DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error) {
+	return stageResumable(a.stagingDir, destinationPath, expectedSHA256, a.rangeFetcher(bucket, obj, obj.ETag))
+}
+
+// rangeFetcher, stageResumable için blob'u 'offset'ten itibaren blok blok
+// okuyan fonksiyonu döndürür. İlk blok hemen istenir; ETag ve toplam boyut bu
+// yanıttan okunur ve sonraki bloklar If-Match ile aynı ETag'e sabitlenir.
+func (a *RealAzureClient) rangeFetcher(bucket string, obj ObjectVersion, pinETag string) rangeFetcher {
+	return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		if ifMatch == "" {
+			ifMatch = pinETag
+		}
+		client, err := a.blobClient(bucket, obj.Key, obj.VersionID)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		reader := &azureBlockReader{client: client, bucket: bucket, key: obj.Key, blockSize: a.blockSize, offset: offset, etag: ifMatch}
+		if err := reader.next(); err != nil {
+			return nil, "", 0, err
+		}
+		return reader, reader.etag, reader.total, nil
+	}
+}
+
+// GetIfChanged, ConditionalFetcher arayüzünü uygular: ilk blok 'If-None-Match'
+// ile istenir; blob değişmemişse (304) ErrNotModified döner.
+func (a *RealAzureClient) GetIfChanged(bucket, key, etag string) (OpenObject, error) {
+	client, err := a.blobClient(bucket, key, "")
+	if err != nil {
+		return nil, err
+	}
+	reader := &azureBlockReader{client: client, bucket: bucket, key: key, blockSize: a.blockSize, notETag: etag}
+	if err := reader.next(); err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotModified {
+			return nil, ErrNotModified
+		}
+		return nil, err
+	}
+	if reader.etag == etag {
+		// Koşullu istekleri desteklemeyen uyumlu sunucu aynı revizyonu tekrar gönderdi.
+		reader.Close()
+		return nil, ErrNotModified
+	}
+	obj := reader.first
+	obj.Key = key
+	return &stagedOpenObject{
+		obj:        obj,
+		etag:       reader.etag,
+		body:       reader,
+		stagingDir: a.stagingDir,
+		ranged:     a.rangeFetcher(bucket, obj, reader.etag),
+	}, nil
+}
+
+// azureBlockReader, bir blob'u 'blockSize' baytlık ranged isteklerle sırayla
+// okuyan io.ReadCloser'dır. İlk bloktan sonraki tüm istekler ilk bloğun ETag'ine
+// sabitlenir; böylece okunan baytlar her zaman aynı revizyona aittir.
+type azureBlockReader struct {
+	client    *blob.Client
+	bucket    string
+	key       string
+	blockSize int64
+
+	offset  int64  // Bir sonraki bloğun başlangıcı
+	etag    string // Sabitlenen ETag (ilk istekte boşsa yanıttan okunur)
+	notETag string // İlk istekte If-None-Match (GetIfChanged)
+	total   int64  // Blob'un toplam boyutu
+	first   ObjectVersion
+	body    io.ReadCloser // Okunmakta olan blok
+}
+
+// next, bir sonraki bloğu ister.
+func (r *azureBlockReader) next() error {
+	cond := &blob.ModifiedAccessConditions{}
+	if r.etag != "" {
+		cond.IfMatch = quotedETag(r.etag)
+	} else if r.notETag != "" {
+		cond.IfNoneMatch = quotedETag(r.notETag)
+	}
+	resp, err := r.client.DownloadStream(context.TODO(), &blob.DownloadStreamOptions{
+		Range:            blob.HTTPRange{Offset: r.offset, Count: r.blockSize},
+		AccessConditions: &blob.AccessConditions{ModifiedAccessConditions: cond},
+	})
+	var respErr *azcore.ResponseError
+	if r.offset == 0 && errors.As(err, &respErr) && respErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// Boş bir blob'da hiçbir aralık geçerli değildir; blob aralıksız istenir.
+		resp, err = r.client.DownloadStream(context.TODO(), &blob.DownloadStreamOptions{
+			AccessConditions: &blob.AccessConditions{ModifiedAccessConditions: cond},
+		})
+	}
+	if err != nil {
+		if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusPreconditionFailed || respErr.StatusCode == http.StatusRequestedRangeNotSatisfiable) {
+			return fmt.Errorf("azure Download (%s/%s): %w", r.bucket, r.key, errStalePartial)
+		}
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotModified {
+			return err
+		}
+		return azureError("Download", r.bucket, r.key, err)
+	}
+
+	etag := azureETag(resp.ETag)
+	if r.etag != "" && etag != r.etag {
+		// If-Match'i yok sayan uyumlu sunucular için.
+		resp.Body.Close()
+		return fmt.Errorf("azure Download (%s/%s): %w", r.bucket, r.key, errStalePartial)
+	}
+	if r.etag == "" {
+		r.etag = etag
+		r.first = ObjectVersion{
+			ETag:         etag,
+			VersionID:    deref(resp.VersionID),
+			LastModified: deref(resp.LastModified),
+		}
+		if len(resp.Metadata) > 0 {
+			r.first.Metadata = make(map[string]string, len(resp.Metadata))
+			for name, value := range resp.Metadata {
+				r.first.Metadata[strings.ToLower(name)] = deref(value)
+			}
+		}
+	}
+
+	// Toplam boyut "bytes 0-8388607/104857600" başlığından okunur.
+	r.total = r.offset + deref(resp.ContentLength)
+	if cr := deref(resp.ContentRange); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				r.total = n
+			}
+		}
+	}
+	r.first.Size = r.total
+	r.offset += deref(resp.ContentLength)
+	r.body = resp.Body
+	return nil
+}
+
+// Read, mevcut bloğun gövdesinden okur; blok bitince bir sonraki blok istenir.
+func (r *azureBlockReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if r.offset >= r.total {
+				return 0, io.EOF
+			}
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.body.Read(p)
+		if err == io.EOF {
+			r.body.Close()
+			r.body = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close, açık bloğun gövdesini (varsa) kapatır.
+func (r *azureBlockReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// azureError, bir Azure hatasını çevirir; olmayan blob ve container'lar
+// ErrObjectNotFound döner.
+func azureError(op, bucket, key string, err error) error {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("azure %s (%s/%s): %w", op, bucket, key, ErrObjectNotFound)
+	}
+	return fmt.Errorf("azure %s (%s/%s) hatası: %w", op, bucket, key, err)
+}
+
+// azureETag, Azure'un tırnaklı ETag'ini ("0x8DC...") ObjectVersion biçimine çevirir.
+func azureETag(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+	return strings.Trim(string(*etag), `"`)
+}
+
+// quotedETag, azureETag'in tersidir: ETag'i istek başlıkları için tırnaklar.
+func quotedETag(etag string) *azcore.ETag {
+	e := azcore.ETag(`"` + etag + `"`)
+	return &e
+}
+
+// deref, nil olabilen bir SDK alanının değerini (nil ise sıfır değerini) döndürür.
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
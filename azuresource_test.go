@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// azuriteKey, Azurite emülatörünün herkesçe bilinen varsayılan hesap anahtarıdır.
+const azuriteKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+// fakeAzurite, Azurite'in ('devstoreaccount1' hesabı, 'models' container'ı)
+// blob okuma, ranged indirme ve listeleme davranışını taklit eder.
+type fakeAzurite struct {
+	mu       sync.Mutex
+	blobs    map[string][]byte
+	etags    map[string]string
+	gets     int
+	auth     []string     // Her isteğin Authorization başlığı veya SAS imzası
+	afterGet func(string) // Her GET yanıtından sonra çağrılır (revizyon değiştirmek için)
+}
+
+func newFakeAzurite(t *testing.T) (*fakeAzurite, *httptest.Server) {
+	f := &fakeAzurite{blobs: make(map[string][]byte), etags: make(map[string]string)}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeAzurite) put(name string, content []byte, etag string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blobs[name], f.etags[name] = content, etag
+}
+
+func (f *fakeAzurite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		auth = "sig=" + r.URL.Query().Get("sig")
+	}
+	f.auth = append(f.auth, auth)
+
+	name, ok := strings.CutPrefix(r.URL.Path, "/devstoreaccount1/models")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("comp") == "list" {
+		prefix := r.URL.Query().Get("prefix")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="models"><Blobs>`)
+		for blobName, content := range f.blobs {
+			if strings.HasPrefix(blobName, prefix) {
+				fmt.Fprintf(w, `<Blob><Name>%s</Name><Properties><Last-Modified>Sun, 01 Mar 2026 12:00:00 GMT</Last-Modified><Etag>"%s"</Etag><Content-Length>%d</Content-Length></Properties></Blob>`,
+					blobName, f.etags[blobName], len(content))
+			}
+		}
+		fmt.Fprint(w, `</Blobs><NextMarker/></EnumerationResults>`)
+		return
+	}
+
+	name = strings.TrimPrefix(name, "/")
+	content, ok := f.blobs[name]
+	if !ok {
+		w.Header().Set("x-ms-error-code", "BlobNotFound")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	etag := `"` + f.etags[name] + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", "Sun, 01 Mar 2026 12:00:00 GMT")
+	w.Header().Set("x-ms-meta-SHA256", sha256Hex(content))
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		return
+	}
+
+	f.gets++
+	if f.afterGet != nil {
+		defer f.afterGet(name)
+	}
+	if m := r.Header.Get("If-Match"); m != "" && m != etag {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	rng := r.Header.Get("x-ms-range")
+	if rng == "" {
+		w.Write(content)
+		return
+	}
+	var start, end int
+	fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+	if start >= len(content) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	end = min(end, len(content)-1)
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+	w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(content[start : end+1])
+}
+
+func newTestAzureClient(t *testing.T, endpoint string, az AzureSourceConfig) *RealAzureClient {
+	az.AccountName, az.Endpoint, az.BlockSizeBytes = "devstoreaccount1", endpoint+"/devstoreaccount1", 4
+	client, err := NewRealAzureClient(&Config{Azure: az}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealAzureClient() hata döndürdü: %v", err)
+	}
+	return client
+}
+
+// TestRealAzureClient_BlockDownload, shared key ile imzalanan isteklerle
+// revizyonun okunduğunu ve modelin birden fazla blokta indirildiğini test eder.
+func TestRealAzureClient_BlockDownload(t *testing.T) {
+	fake, server := newFakeAzurite(t)
+	content := []byte("model ağırlıkları (blok blok)")
+	fake.put("prod/model.bin", content, "0x8DC1")
+	t.Setenv("AZURE_STORAGE_KEY", azuriteKey)
+	client := newTestAzureClient(t, server.URL, AzureSourceConfig{AccountKey: "$AZURE_STORAGE_KEY"})
+
+	obj, err := client.HeadObject("models", "prod/model.bin", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	if obj.ETag != "0x8DC1" || obj.Size != int64(len(content)) || obj.Metadata["sha256"] != sha256Hex(content) {
+		t.Errorf("Beklenmeyen revizyon bilgisi: %+v", obj)
+	}
+
+	dest := filepath.Join(t.TempDir(), "model.bin")
+	digest, err := client.DownloadObject("models", obj, dest, obj.Metadata["sha256"])
+	if err != nil {
+		t.Fatalf("DownloadObject() hata döndürdü: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if digest != sha256Hex(content) || string(got) != string(content) {
+		t.Errorf("Model hedefe yazılmalıydı (özet: %s)", digest)
+	}
+	if want := (len(content) + 3) / 4; fake.gets != want {
+		t.Errorf("%d blok isteği bekleniyordu, alınan: %d", want, fake.gets)
+	}
+	for _, auth := range fake.auth {
+		if !strings.HasPrefix(auth, "SharedKey devstoreaccount1:") {
+			t.Errorf("İstek shared key ile imzalanmalıydı: %q", auth)
+		}
+	}
+
+	if _, err := client.HeadObject("models", "prod/missing.bin", ""); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("ErrObjectNotFound bekleniyordu, alınan: %v", err)
+	}
+}
+
+// TestRealAzureClient_ETagChangesMidDownload, indirme sırasında blob
+// değişirse sonraki blokların If-Match ile reddedildiğini ve karışık
+// revizyonlu bir dosyanın hedefe yazılmadığını test eder.
+func TestRealAzureClient_ETagChangesMidDownload(t *testing.T) {
+	fake, server := newFakeAzurite(t)
+	fake.put("prod/model.bin", []byte("eski model içeriği"), "0x8DC1")
+	client := newTestAzureClient(t, server.URL, AzureSourceConfig{SASToken: "?sv=2024-08-04&sig=imza"})
+
+	obj, err := client.HeadObject("models", "prod/model.bin", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	fake.afterGet = func(name string) {
+		fake.blobs[name], fake.etags[name] = []byte("yeni model içeriği"), "0x8DC2"
+	}
+
+	dest := filepath.Join(t.TempDir(), "model.bin")
+	if _, err := client.DownloadObject("models", obj, dest, ""); !errors.Is(err, errStalePartial) {
+		t.Errorf("errStalePartial bekleniyordu, alınan: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Karışık revizyonlu dosya hedefe yazılmamalıydı")
+	}
+	for _, auth := range fake.auth {
+		if auth != "sig=imza" {
+			t.Errorf("İstek SAS token'ı ile yapılmalıydı: %q", auth)
+		}
+	}
+}
+
+// TestRealAzureClient_ListAndConditional, prefix listelemesini ve koşullu
+// GET'in değişmeyen blob için ErrNotModified döndürdüğünü test eder.
+func TestRealAzureClient_ListAndConditional(t *testing.T) {
+	fake, server := newFakeAzurite(t)
+	fake.put("prod/releases/model-1.0.0.bin", []byte("v1"), "0x8DC1")
+	fake.put("staging/model.bin", []byte("st"), "0x8DC9")
+	client := newTestAzureClient(t, server.URL, AzureSourceConfig{})
+
+	objects, err := client.ListObjects("models", "prod/releases/")
+	if err != nil {
+		t.Fatalf("ListObjects() hata döndürdü: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "prod/releases/model-1.0.0.bin" || objects[0].ETag != "0x8DC1" ||
+		objects[0].Size != 2 || !objects[0].LastModified.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Beklenmeyen liste: %+v", objects)
+	}
+
+	if _, err := client.GetIfChanged("models", "staging/model.bin", "0x8DC9"); !errors.Is(err, ErrNotModified) {
+		t.Errorf("ErrNotModified bekleniyordu, alınan: %v", err)
+	}
+	opened, err := client.GetIfChanged("models", "staging/model.bin", "0x8DC1")
+	if err != nil {
+		t.Fatalf("GetIfChanged() hata döndürdü: %v", err)
+	}
+	defer opened.Close()
+	if obj := opened.Version(); obj.ETag != "0x8DC9" || obj.Size != 2 {
+		t.Errorf("Revizyon bilgisi yanıttan okunmalıydı, alınan: %+v", obj)
+	}
+}
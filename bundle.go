@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	manifestFileName   = "manifest.json" // Paket dizininde manifestonun saklandığı dosya
+	tmpDirSuffix       = ".tmp"          // Tamamlanmamış (paket veya arşiv) dizininin son eki
+	incomingSubdir     = ".incoming"     // Paket dosyalarının indirme sırasında tutulduğu dizin
+	defaultParallelism = 4
+)
+
+// ErrManifestInvalid, paket manifestosu okunamadığında veya kurallara
+// uymadığında döndürülür. Böyle bir sürüm karantinaya alınır.
+var ErrManifestInvalid = errors.New("geçersiz paket manifestosu")
+
+// BundleManifest, birlikte değişmesi gereken dosyalardan (model, tokenizer,
+// etiket listesi, yapılandırma...) oluşan bir model paketini tanımlar.
+//
+//	{"files": [
+//	  {"path": "model.onnx", "size": 104857600, "sha256": "9f86d0..."},
+//	  {"path": "tokenizer/vocab.txt", "key": "shared/vocab-v3.txt", "size": 231508, "sha256": "2c26b4..."}
+//	]}
+type BundleManifest struct {
+	Files []BundleFile `json:"files"`
+}
+
+// BundleFile, paketteki tek bir dosyadır.
+type BundleFile struct {
+	Path      string `json:"path"`       // Paket dizinindeki göreli yol ('/' ile ayrılmış)
+	Key       string `json:"key"`        // Kaynaktaki anahtar (boşsa manifestonun dizinine göre 'path')
+	VersionID string `json:"version_id"` // İsteğe bağlı: belirli bir S3 revizyonu
+	Size      int64  `json:"size"`       // Bayt cinsinden boyut
+	SHA256    string `json:"sha256"`     // Zorunlu içerik özeti (hex veya base64)
+}
+
+// parseManifest, manifestoyu okur ve doğrular. Yollar göreli olmalı, paket
+// dizininin dışına çıkmamalı ve tekrar etmemelidir; her dosyanın özeti zorunludur.
+func parseManifest(data []byte) (*BundleManifest, error) {
+	var m BundleManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrManifestInvalid, err)
+	}
+	if len(m.Files) == 0 {
+		return nil, fmt.Errorf("%w: dosya listesi boş", ErrManifestInvalid)
+	}
+
+	seen := make(map[string]bool)
+	for i := range m.Files {
+		f := &m.Files[i]
+		clean := path.Clean(f.Path)
+		if f.Path == "" || path.IsAbs(f.Path) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") ||
+			strings.Contains(f.Path, `\`) || clean == manifestFileName || strings.HasPrefix(clean, incomingSubdir) {
+			return nil, fmt.Errorf("%w: geçersiz dosya yolu '%s'", ErrManifestInvalid, f.Path)
+		}
+		if seen[clean] {
+			return nil, fmt.Errorf("%w: '%s' birden fazla kez listelenmiş", ErrManifestInvalid, f.Path)
+		}
+		seen[clean] = true
+		f.Path = clean
+
+		if f.SHA256 == "" {
+			return nil, fmt.Errorf("%w: '%s' için sha256 zorunludur", ErrManifestInvalid, f.Path)
+		}
+		sum, err := normalizeSHA256(f.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s': %v", ErrManifestInvalid, f.Path, err)
+		}
+		f.SHA256 = sum
+		if f.Size < 0 {
+			return nil, fmt.Errorf("%w: '%s' için geçersiz boyut", ErrManifestInvalid, f.Path)
+		}
+	}
+	return &m, nil
+}
+
+// bundleDownloadPath, manifestonun indirileceği geçici yolu döndürür.
+// Paket, 'bundleDir' yerine önce '<bundleDir>.tmp' altında toplanır.
+func bundleDownloadPath(bundleDir string) string {
+	return filepath.Join(bundleDir+tmpDirSuffix, manifestFileName)
+}
+
+// assembleBundle, '<bundleDir>.tmp' altına indirilmiş manifestoyu okur, listelenen
+// dosyaları paralel olarak indirip doğrular ve paket tamamlandığında dizini
+// tek bir rename ile 'bundleDir' olarak yerine taşır. Böylece 'bundleDir' ya hiç
+// yoktur ya da tüm dosyaları doğrulanmış olarak vardır.
+// Geçici dizinde özeti zaten eşleşen veya içerik deposunda bulunan dosyalar
+// tekrar indirilmez.
+func (p *Poller) assembleBundle(manifestKey, bundleDir string) (*BundleManifest, error) {
+	tmpDir := bundleDir + tmpDirSuffix
+	data, err := os.ReadFile(filepath.Join(tmpDir, manifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("paket manifestosu okunamadı: %w", err)
+	}
+	manifest, err := parseManifest(data)
+	if err != nil {
+		os.RemoveAll(tmpDir) // Bu sürüm karantinaya alınacak; geçici dosyaları tutmanın anlamı yok.
+		return nil, err
+	}
+	p.log.Printf("[Bundle] Manifesto okundu: %d dosya.", len(manifest.Files))
+
+	// Boyut sınırı tüm pakete uygulanır; disk alanı ise sadece depoda
+	// bulunmayan (gerçekten indirilecek) dosyalar için gerekir.
+	var total, needed int64
+	for _, f := range manifest.Files {
+		total += f.Size
+		if p.cas == nil || !p.cas.Contains(f.SHA256) {
+			needed += f.Size
+		}
+	}
+	if max := p.cfg.Disk.MaxModelBytes; max > 0 && total > max {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("%w: paket toplamı %d bayt (sınır: %d bayt)", ErrModelTooLarge, total, max)
+	}
+	if err := p.ensureDiskSpace(needed, tmpDir); err != nil {
+		return nil, err
+	}
+
+	parallelism := p.cfg.BundleParallelism
+	if parallelism <= 0 {
+		parallelism = defaultParallelism
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, parallelism)
+	)
+	for i, f := range manifest.Files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := p.fetchBundleFile(manifestKey, tmpDir, i, f); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		if errors.Is(firstErr, ErrChecksumMismatch) || errors.Is(firstErr, ErrManifestInvalid) {
+			os.RemoveAll(tmpDir)
+		}
+		return nil, firstErr
+	}
+
+	// Tüm dosyalar doğrulandı: paketi atomik olarak yerine taşı.
+	os.RemoveAll(filepath.Join(tmpDir, incomingSubdir))
+	if err := os.RemoveAll(bundleDir); err != nil {
+		return nil, fmt.Errorf("eski paket dizini silinemedi (%s): %w", bundleDir, err)
+	}
+	if err := os.Rename(tmpDir, bundleDir); err != nil {
+		return nil, fmt.Errorf("paket dizini yerine taşınamadı (%s): %w", bundleDir, err)
+	}
+	syncDir(filepath.Dir(bundleDir))
+	p.log.Printf("[Bundle] Paket hazır: %s", bundleDir)
+	return manifest, nil
+}
+
+// fetchBundleFile, paketteki tek bir dosyayı indirir, boyutunu ve özetini
+// doğrular ve geçici paket dizinindeki yerine taşır.
+func (p *Poller) fetchBundleFile(manifestKey, tmpDir string, index int, f BundleFile) error {
+	dest := filepath.Join(tmpDir, filepath.FromSlash(f.Path))
+	if sum, err := fileSHA256(dest); err == nil && sum == f.SHA256 {
+		return nil // Önceki denemede indirilmiş ve doğrulanmış.
+	}
+	if p.fetchFromStore(f.SHA256, dest) {
+		return nil // Aynı içerik başka bir sürümde zaten indirilmiş.
+	}
+
+	key := f.Key
+	if key == "" {
+		key = path.Join(path.Dir(manifestKey), f.Path)
+	}
+
+	// Dosyalar önce sıra numarasıyla ayrılmış düz bir dizine indirilir; farklı
+	// alt dizinlerdeki aynı adlı dosyaların staging'de çakışmaması için.
+	incoming := filepath.Join(tmpDir, incomingSubdir, fmt.Sprintf("%d-%s", index, path.Base(f.Path)))
+	if _, err := p.s3.DownloadObject(p.cfg.S3Bucket, ObjectVersion{Key: key, VersionID: f.VersionID}, incoming, f.SHA256); err != nil {
+		return fmt.Errorf("paket dosyası '%s' indirilemedi: %w", f.Path, err)
+	}
+	if f.Size > 0 {
+		info, err := os.Stat(incoming)
+		if err != nil {
+			return err
+		}
+		if info.Size() != f.Size {
+			os.Remove(incoming)
+			return fmt.Errorf("%w: '%s' boyutu %d bayt, manifestoda %d", ErrManifestInvalid, f.Path, info.Size(), f.Size)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(incoming, dest); err != nil {
+		return fmt.Errorf("paket dosyası yerine taşınamadı (%s): %w", dest, err)
+	}
+	syncDir(filepath.Dir(dest))
+	p.ingest(dest, f.SHA256)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseManifest(t *testing.T) {
+	digest := sha256Hex([]byte("x"))
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"geçerli", fmt.Sprintf(`{"files":[{"path":"model.onnx","sha256":"%s"},{"path":"tok/vocab.txt","sha256":"%s"}]}`, digest, digest), false},
+		{"boş liste", `{"files":[]}`, true},
+		{"özet yok", `{"files":[{"path":"model.onnx"}]}`, true},
+		{"dizin dışına çıkan yol", fmt.Sprintf(`{"files":[{"path":"../etc/passwd","sha256":"%s"}]}`, digest), true},
+		{"mutlak yol", fmt.Sprintf(`{"files":[{"path":"/etc/passwd","sha256":"%s"}]}`, digest), true},
+		{"tekrar eden yol", fmt.Sprintf(`{"files":[{"path":"a","sha256":"%s"},{"path":"./a","sha256":"%s"}]}`, digest, digest), true},
+		{"manifesto adı", fmt.Sprintf(`{"files":[{"path":"manifest.json","sha256":"%s"}]}`, digest), true},
+		{"bozuk JSON", `{"files":`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseManifest() hata = %v, beklenen hata: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrManifestInvalid) {
+				t.Errorf("Hata ErrManifestInvalid olmalıydı: %v", err)
+			}
+		})
+	}
+}
+
+// newBundleS3, bir manifesto ve listelediği dosyaları içeren sahte bir S3 döndürür.
+func newBundleS3(modelDigest string) *MockS3Client {
+	model := []byte("model ağırlıkları")
+	labels := []byte("kedi\nköpek\n")
+	manifest := fmt.Sprintf(`{"files":[
+		{"path":"model.onnx","size":%d,"sha256":"%s"},
+		{"path":"labels/labels.txt","key":"shared/labels-v2.txt","sha256":"%s"}
+	]}`, len(model), modelDigest, sha256Hex(labels))
+
+	return &MockS3Client{
+		EtagToReturn: "v2",
+		Objects: map[string][]byte{
+			"prod/bundle.json":     []byte(manifest),
+			"prod/model.onnx":      model,
+			"shared/labels-v2.txt": labels,
+		},
+	}
+}
+
+// TestPoller_Bundle, manifestoda listelenen dosyaların sürüme özel bir dizine
+// indirildiğini ve aktif bağın bu dizini gösterdiğini test eder.
+func TestPoller_Bundle(t *testing.T) {
+	root := t.TempDir()
+	mockCfg := &Config{DataDir: root, S3Key: "prod/bundle.json", DeployScriptPath: "deploy.sh", Bundle: true}
+	mockS3 := newBundleS3(sha256Hex([]byte("model ağırlıkları")))
+	mockLink := &MockLinker{}
+	mockDeploy := &MockDeployer{}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, &MockStateStore{State: &AgentState{ETag: "v1"}}, &MockHealthProber{}, filepath.Join(root, "active_model_link"))
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	bundleDir := filepath.Join(root, modelsSubdir, "bundle-v2")
+	for _, name := range []string{manifestFileName, "model.onnx", filepath.Join("labels", "labels.txt")} {
+		if _, err := os.Stat(filepath.Join(bundleDir, name)); err != nil {
+			t.Errorf("Paket dosyası '%s' bulunamadı: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(bundleDir, incomingSubdir)); !os.IsNotExist(err) {
+		t.Errorf("Geçici indirme dizini silinmeliydi")
+	}
+	if _, err := os.Stat(bundleDir + tmpDirSuffix); !os.IsNotExist(err) {
+		t.Errorf("Geçici paket dizini kalmamalıydı")
+	}
+	if len(mockLink.Calls) != 1 || mockLink.Calls[0] != "SET "+filepath.Join(root, "active_model_link")+" -> "+bundleDir {
+		t.Errorf("Aktif bağ paket dizinini göstermeliydi, çağrılar: %v", mockLink.Calls)
+	}
+	if mockDeploy.Calls[0] != "deploy.sh --test "+bundleDir {
+		t.Errorf("Test script'i paket diziniyle çağrılmalıydı: %v", mockDeploy.Calls)
+	}
+}
+
+// TestPoller_BundleChecksumMismatch, paketteki bir dosyanın özeti tutmazsa
+// paketin hiç aktif edilmediğini ve sürümün karantinaya alındığını test eder.
+func TestPoller_BundleChecksumMismatch(t *testing.T) {
+	root := t.TempDir()
+	mockCfg := &Config{DataDir: root, S3Key: "prod/bundle.json", DeployScriptPath: "deploy.sh", Bundle: true}
+	mockS3 := newBundleS3(sha256Hex([]byte("başka bir model")))
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, filepath.Join(root, "active_model_link"))
+	err := p.RunOnce()
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("RunOnce() ErrChecksumMismatch döndürmeliydi, alınan: %v", err)
+	}
+	if len(mockLink.Calls) != 0 {
+		t.Errorf("Bozuk paket aktif edilmemeliydi, çağrılar: %v", mockLink.Calls)
+	}
+	bundleDir := filepath.Join(root, modelsSubdir, "bundle-v2")
+	for _, dir := range []string{bundleDir, bundleDir + tmpDirSuffix} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("'%s' dizini kalmamalıydı", dir)
+		}
+	}
+	if _, ok := mockStore.State.Quarantine["v2"]; !ok {
+		t.Errorf("Sürüm karantinaya alınmalıydı")
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	storeSubdir    = "store" // İçerik adresli model deposu (blobs/ ve refs/)
+	defaultRefName = "default"
+)
+
+// ContentStore, model dosyalarını SHA-256 özetlerine göre saklayan içerik
+// adresli bir depodur. 'models/' altındaki dosyalar depodaki blob'lara sabit
+// bağlantıdır (hardlink); aynı içerik farklı bir ETag veya anahtarla tekrar
+// yayınlansa bile diskte bir kez bulunur ve tekrar indirilmez.
+//
+//	store/blobs/sha256/<ilk 2 karakter>/<özet>   (salt okunur)
+//	store/refs/<hedef>/<revizyon>.json            (sürümün kullandığı blob'lar)
+type ContentStore struct {
+	root string
+}
+
+// VersionRef, bir model sürümünün depoda hangi blob'ları kullandığını kaydeder.
+type VersionRef struct {
+	Version   string    `json:"version"` // VersionID veya ETag
+	ETag      string    `json:"etag"`
+	Key       string    `json:"key,omitempty"`
+	ModelPath string    `json:"model_path"` // 'models/' altındaki dosya veya dizin
+	Files     []RefFile `json:"files"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RefFile, bir sürümün kullandığı tek bir blob'dur.
+type RefFile struct {
+	Path   string `json:"path"` // Sürüm içindeki göreli ad (örn: "model-v2.bin", "tokenizer/vocab.txt")
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// NewContentStore, 'root' dizininde bir içerik deposu oluşturur.
+func NewContentStore(root string) *ContentStore {
+	return &ContentStore{root: root}
+}
+
+// blobPath, özeti verilen blob'un yolunu döndürür.
+func (cs *ContentStore) blobPath(digest string) string {
+	digest = strings.ToLower(digest)
+	prefix := digest
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(cs.root, "blobs", "sha256", prefix, digest)
+}
+
+// Has, depoda bu özete sahip sağlam bir blob olup olmadığını döndürür.
+// Diskte bozulmuş bir blob bulunursa silinir ve false döner.
+func (cs *ContentStore) Has(digest string) bool {
+	if _, err := normalizeSHA256(digest); err != nil {
+		return false
+	}
+	blob := cs.blobPath(digest)
+	sum, err := fileSHA256(blob)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(sum, digest) {
+		os.Remove(blob)
+		return false
+	}
+	return true
+}
+
+// Contains, bu özete sahip bir blob'un depoda bulunup bulunmadığını içeriği
+// okumadan (sadece varlığına bakarak) döndürür. Kullanmadan önce Has ile doğrulanmalıdır.
+func (cs *ContentStore) Contains(digest string) bool {
+	if digest == "" {
+		return false
+	}
+	_, err := os.Stat(cs.blobPath(digest))
+	return err == nil
+}
+
+// LinkTo, depodaki blob'u 'dest' yoluna sabit bağlantı olarak (atomik) yerleştirir.
+func (cs *ContentStore) LinkTo(digest, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".link" + tmpDirSuffix
+	os.Remove(tmp)
+	if err := os.Link(cs.blobPath(digest), tmp); err != nil {
+		return fmt.Errorf("blob bağlanamadı (%s): %w", dest, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("blob yerine taşınamadı (%s): %w", dest, err)
+	}
+	syncDir(filepath.Dir(dest))
+	return nil
+}
+
+// Ingest, özeti bilinen bir dosyayı depoya ekler. Aynı içerik depoda zaten
+// varsa dosya depodaki blob'a bağlantıyla değiştirilir (kopya silinir).
+// Blob'lar salt okunur yapılır; böylece bir model dosyasının yerinde
+// değiştirilmesi aynı içeriği kullanan diğer sürümleri bozamaz.
+func (cs *ContentStore) Ingest(path, digest string) error {
+	blob := cs.blobPath(digest)
+	if blobInfo, err := os.Stat(blob); err == nil {
+		if info, err := os.Stat(path); err == nil && os.SameFile(info, blobInfo) {
+			return nil
+		}
+		return cs.LinkTo(digest, path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blob), 0o755); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o444); err != nil {
+		return err
+	}
+	if err := os.Link(path, blob); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return cs.LinkTo(digest, path) // Aynı anda başka bir hedef eklemiş.
+		}
+		return fmt.Errorf("dosya depoya eklenemedi (%s): %w", path, err)
+	}
+	syncDir(filepath.Dir(blob))
+	return nil
+}
+
+// refPath, bir hedefin bir sürümüne ait kayıt dosyasının yolunu döndürür.
+func (cs *ContentStore) refPath(target, version string) string {
+	if target == "" {
+		target = defaultRefName
+	}
+	return filepath.Join(cs.root, "refs", target, fileSafe(version)+".json")
+}
+
+// WriteRef, bir sürümün kullandığı blob'ları atomik olarak kaydeder.
+func (cs *ContentStore) WriteRef(target string, ref VersionRef) error {
+	data, err := json.MarshalIndent(ref, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cs.refPath(target, ref.Version), data)
+}
+
+// Refs, bir hedefin kayıtlı tüm sürümlerini (en yeniden eskiye) döndürür.
+func (cs *ContentStore) Refs(target string) ([]VersionRef, error) {
+	if target == "" {
+		target = defaultRefName
+	}
+	dir := filepath.Join(cs.root, "refs", target)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []VersionRef
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var ref VersionRef
+		if err := json.Unmarshal(data, &ref); err != nil {
+			continue // Bozuk kayıt; sürüm bilgisi kaybolur ama depo kullanılabilir kalır.
+		}
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].CreatedAt.After(refs[j].CreatedAt) })
+	return refs, nil
+}
+
+// fetchFromStore, içerik deposu açıksa ve 'digest' depoda varsa blob'u 'dest'
+// yoluna bağlar ve true döndürür. Böylece aynı içerik tekrar indirilmez.
+func (p *Poller) fetchFromStore(digest, dest string) bool {
+	if p.cas == nil || digest == "" || !p.cas.Has(digest) {
+		return false
+	}
+	if err := p.cas.LinkTo(digest, dest); err != nil {
+		p.log.Printf("[Store] UYARI: %v", err)
+		return false
+	}
+	p.log.Printf("[Store] İçerik (%s) yerel depoda bulundu, indirme atlandı: %s", digest, dest)
+	return true
+}
+
+// ingest, indirilen bir dosyayı içerik deposuna ekler. Depo kullanılamıyorsa
+// (örn: farklı dosya sistemi, sabit bağlantı desteği yok) sadece uyarı verilir.
+func (p *Poller) ingest(path, digest string) {
+	if p.cas == nil || digest == "" {
+		return
+	}
+	if err := p.cas.Ingest(path, digest); err != nil {
+		p.log.Printf("[Store] UYARI: Dosya depoya eklenemedi, tekilleştirme yapılmadı: %v", err)
+	}
+}
+
+// recordRef, yeni indirilen sürümün kullandığı blob'ları depoya kaydeder.
+func (p *Poller) recordRef(entry *JournalEntry, files []RefFile) {
+	if p.cas == nil {
+		return
+	}
+	for i := range files {
+		if info, err := os.Stat(p.cas.blobPath(files[i].SHA256)); err == nil {
+			files[i].Size = info.Size()
+		}
+	}
+	ref := VersionRef{
+		Version:   entry.ID(),
+		ETag:      entry.ETag,
+		Key:       entry.Key,
+		ModelPath: entry.ModelPath,
+		Files:     files,
+		CreatedAt: time.Now(),
+	}
+	if err := p.cas.WriteRef(p.cfg.Name, ref); err != nil {
+		p.log.Printf("[Store] UYARI: Sürüm kaydı yazılamadı: %v", err)
+	}
+}
+
+// StoreRefs, bu hedefin içerik deposunda kayıtlı sürümlerini döndürür.
+// İçerik deposu kapalıysa nil döner.
+func (p *Poller) StoreRefs() ([]VersionRef, error) {
+	if p.cas == nil {
+		return nil, nil
+	}
+	return p.cas.Refs(p.cfg.Name)
+}
+
+// Prune, hiçbir hedefin hiçbir sürüm kaydında kullanılmayan blob'ları siler ve
+// silinen blob sayısını döndürür. Başka bir hedefin o anda eklediği ama henüz
+// kaydını yazmadığı bir blob silinirse sadece tekilleştirme kaybolur; model
+// dosyası sabit bağlantı olduğu için etkilenmez.
+func (cs *ContentStore) Prune() (int, error) {
+	used := make(map[string]bool)
+	targets, err := os.ReadDir(filepath.Join(cs.root, "refs"))
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	for _, t := range targets {
+		if !t.IsDir() {
+			continue
+		}
+		refs, err := cs.Refs(t.Name())
+		if err != nil {
+			return 0, err
+		}
+		for _, ref := range refs {
+			for _, f := range ref.Files {
+				used[strings.ToLower(f.SHA256)] = true
+			}
+		}
+	}
+
+	removed := 0
+	err = filepath.WalkDir(filepath.Join(cs.root, "blobs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || used[d.Name()] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
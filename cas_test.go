@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentStore_Dedupe(t *testing.T) {
+	dir := t.TempDir()
+	cs := NewContentStore(filepath.Join(dir, storeSubdir))
+	content := []byte("ortak model ağırlıkları")
+	digest := sha256Hex(content)
+
+	first := filepath.Join(dir, "models", "model-v1.bin")
+	second := filepath.Join(dir, "models", "model-v2.bin")
+	os.MkdirAll(filepath.Dir(first), 0o755)
+	os.WriteFile(first, content, 0o644)
+	os.WriteFile(second, content, 0o644)
+
+	if err := cs.Ingest(first, digest); err != nil {
+		t.Fatalf("Ingest() hata döndürdü: %v", err)
+	}
+	if err := cs.Ingest(second, digest); err != nil {
+		t.Fatalf("Ingest() hata döndürdü: %v", err)
+	}
+
+	// Aynı içerik diskte tek bir kez bulunmalı.
+	a, _ := os.Stat(first)
+	b, _ := os.Stat(second)
+	blob, _ := os.Stat(cs.blobPath(digest))
+	if !os.SameFile(a, b) || !os.SameFile(a, blob) {
+		t.Error("Aynı içerikli dosyalar depodaki tek blob'a bağlanmalıydı")
+	}
+	if blob.Mode().Perm()&0o222 != 0 {
+		t.Errorf("Blob salt okunur olmalıydı, izinler: %v", blob.Mode().Perm())
+	}
+
+	linked := filepath.Join(dir, "models", "model-v3.bin")
+	if err := cs.LinkTo(digest, linked); err != nil {
+		t.Fatalf("LinkTo() hata döndürdü: %v", err)
+	}
+	if got, _ := os.ReadFile(linked); string(got) != string(content) {
+		t.Errorf("Bağlanan dosyanın içeriği yanlış: %q", got)
+	}
+}
+
+func TestContentStore_CorruptBlob(t *testing.T) {
+	dir := t.TempDir()
+	cs := NewContentStore(dir)
+	digest := sha256Hex([]byte("model"))
+
+	if cs.Has(digest) {
+		t.Fatal("Boş depoda blob bulunmamalıydı")
+	}
+	os.MkdirAll(filepath.Dir(cs.blobPath(digest)), 0o755)
+	os.WriteFile(cs.blobPath(digest), []byte("bozulmuş"), 0o444)
+	if cs.Has(digest) {
+		t.Error("Özeti tutmayan blob kullanılmamalıydı")
+	}
+	if _, err := os.Stat(cs.blobPath(digest)); !os.IsNotExist(err) {
+		t.Error("Bozulmuş blob silinmeliydi")
+	}
+}
+
+func TestContentStore_Refs(t *testing.T) {
+	cs := NewContentStore(t.TempDir())
+	now := time.Now()
+	cs.WriteRef("", VersionRef{Version: "v1", CreatedAt: now.Add(-time.Hour)})
+	cs.WriteRef("", VersionRef{Version: "v2", CreatedAt: now})
+	cs.WriteRef("vision", VersionRef{Version: "v9", CreatedAt: now})
+
+	refs, err := cs.Refs("")
+	if err != nil {
+		t.Fatalf("Refs() hata döndürdü: %v", err)
+	}
+	if len(refs) != 2 || refs[0].Version != "v2" || refs[1].Version != "v1" {
+		t.Errorf("Sürümler en yeniden eskiye sıralanmalıydı, alınan: %+v", refs)
+	}
+}
+
+// TestPoller_ContentStoreSkipsDownload, aynı içeriğin yeni bir ETag ile
+// yayınlanması durumunda modelin tekrar indirilmediğini test eder.
+func TestPoller_ContentStoreSkipsDownload(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("aynı model, yeni etiket")
+	digest := sha256Hex(content)
+
+	mockS3 := &MockS3Client{EtagToReturn: "v2", ExpectedSHA256ToReturn: digest, Objects: map[string][]byte{"prod/model.bin": content}}
+	mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", ContentStore: true}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1", SHA256: digest}}
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	// v1 daha önce indirilmiş ve depoya eklenmiş.
+	old := filepath.Join(dir, modelsSubdir, "model-v1.bin")
+	os.MkdirAll(filepath.Dir(old), 0o755)
+	os.WriteFile(old, content, 0o644)
+	if err := p.cas.Ingest(old, digest); err != nil {
+		t.Fatalf("Ingest() hata döndürdü: %v", err)
+	}
+
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if mockS3.DownloadCalls != 0 {
+		t.Errorf("İçerik depoda varken indirme yapılmamalıydı (indirme sayısı: %d)", mockS3.DownloadCalls)
+	}
+	if mockStore.State.ETag != "v2" {
+		t.Errorf("Yeni ETag kaydedilmeliydi, alınan: %s", mockStore.State.ETag)
+	}
+
+	refs, _ := p.StoreRefs()
+	if len(refs) != 1 || refs[0].Version != "v2" || len(refs[0].Files) != 1 || refs[0].Files[0].SHA256 != digest {
+		t.Errorf("Yeni sürümün kaydı yazılmalıydı, alınan: %+v", refs)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrChecksumMismatch, indirilen dosyanın SHA-256 özeti beklenen özetle
+// eşleşmediğinde döndürülür. Böyle bir dosya asla 'models/' altına taşınmaz.
+var ErrChecksumMismatch = errors.New("SHA-256 özeti eşleşmiyor")
+
+// verifySHA256, hesaplanan özeti beklenen özetle karşılaştırır.
+// Beklenen özet boşsa doğrulama yapılmaz.
+func verifySHA256(expected, actual string) error {
+	if expected == "" || strings.EqualFold(expected, actual) {
+		return nil
+	}
+	return fmt.Errorf("%w: beklenen %s, hesaplanan %s", ErrChecksumMismatch, expected, actual)
+}
+
+// normalizeSHA256, farklı kaynaklardan gelen SHA-256 özetini küçük harfli hex
+// biçimine çevirir. Hex (64 karakter) ve base64 (S3 ChecksumSHA256) kabul edilir.
+func normalizeSHA256(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == sha256.Size*2 {
+		if _, err := hex.DecodeString(s); err == nil {
+			return strings.ToLower(s), nil
+		}
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil && len(raw) == sha256.Size {
+		return hex.EncodeToString(raw), nil
+	}
+	return "", fmt.Errorf("geçersiz SHA-256 özeti: '%s'", s)
+}
+
+// lookupSHA256, bir revizyon için yayınlanmış SHA-256 özetini bulur: önce
+// 'sha256' metadata'sına, sonra kaynağın kendi özetine (ChecksumSHA256), en
+// son 'fetch' ile '<key>.sha256' yan dosyasına bakılır. Hiçbiri yoksa boş döner.
+//
+// Revizyon belirli bir VersionID'ye sabitlenmişse yan dosyaya bakılmaz: yan
+// dosya her zaman en son sürümüyle okunur ve arada yeni bir model yayınlandıysa
+// eski (ama sağlam) revizyon yenisinin özetiyle karşılaştırılıp karantinaya
+// alınırdı. Versiyonlamalı bucket'larda özet metadata ile yayınlanmalıdır.
+func lookupSHA256(obj ObjectVersion, fetch func(key string) ([]byte, error)) (string, error) {
+	if v, ok := obj.Metadata["sha256"]; ok {
+		return normalizeSHA256(v)
+	}
+	if obj.ChecksumSHA256 != "" {
+		return obj.ChecksumSHA256, nil
+	}
+	if obj.VersionID != "" {
+		return "", nil
+	}
+
+	content, err := fetch(obj.Key + ".sha256")
+	if errors.Is(err, ErrObjectNotFound) {
+		return "", nil // Yayınlanmış bir özet yok.
+	}
+	if err != nil {
+		return "", err
+	}
+	return parseSHA256Sidecar(string(content))
+}
+
+// parseSHA256Sidecar, '<key>.sha256' yan dosyasının içeriğinden özeti okur.
+// 'sha256sum' çıktısı biçimi ("<hex>  model.bin") veya sadece özet kabul edilir.
+func parseSHA256Sidecar(content string) (string, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("SHA-256 yan dosyası boş")
+	}
+	return normalizeSHA256(fields[0])
+}
+
+// fileSHA256, diskteki bir dosyanın SHA-256 özetini hex olarak hesaplar.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
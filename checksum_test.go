@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLookupSHA256, özetin metadata, ChecksumSHA256 ve yan dosya sırasıyla
+// arandığını ve VersionID'ye sabitlenmiş revizyonlarda yan dosyanın
+// (en son sürümü başka bir modele ait olabileceği için) okunmadığını test eder.
+func TestLookupSHA256(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	sidecar := strings.Repeat("cd", 32)
+	tests := []struct {
+		name string
+		obj  ObjectVersion
+		want string
+	}{
+		{"metadata", ObjectVersion{Key: "m.bin", Metadata: map[string]string{"sha256": strings.ToUpper(digest)}}, digest},
+		{"ChecksumSHA256", ObjectVersion{Key: "m.bin", ChecksumSHA256: digest}, digest},
+		{"yan dosya", ObjectVersion{Key: "m.bin"}, sidecar},
+		{"sabitlenmiş sürümde yan dosya yok", ObjectVersion{Key: "m.bin", VersionID: "v1"}, ""},
+		{"sabitlenmiş sürümde metadata", ObjectVersion{Key: "m.bin", VersionID: "v1", Metadata: map[string]string{"sha256": digest}}, digest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lookupSHA256(tt.obj, func(key string) ([]byte, error) {
+				if key != "m.bin.sha256" {
+					t.Errorf("Beklenmedik yan dosya anahtarı: %s", key)
+				}
+				return []byte(sidecar + "  m.bin\n"), nil
+			})
+			if err != nil || got != tt.want {
+				t.Errorf("'%s' bekleniyordu, alınan '%s' (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
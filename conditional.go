@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotModified, koşullu bir istekte nesnenin bilinen ETag'den bu yana
+// değişmediğini belirtir (HTTP 304 Not Modified).
+var ErrNotModified = errors.New("nesne değişmemiş")
+
+// ConditionalFetcher, revizyon kontrolünü ve indirmeyi tek bir istekte
+// yapabilen kaynakların (isteğe bağlı olarak) uyguladığı arayüzdür.
+type ConditionalFetcher interface {
+	// GetIfChanged, nesneyi 'If-None-Match: <etag>' ile ister. Nesne değişmemişse
+	// ErrNotModified döner; değişmişse revizyon bilgisi aynı yanıttan okunur ve
+	// gövdesi henüz okunmamış bir OpenObject döndürülür.
+	GetIfChanged(bucket, key, etag string) (OpenObject, error)
+}
+
+// OpenObject, GetIfChanged ile açılmış bir nesnedir.
+type OpenObject interface {
+	// Version, yanıttan okunan revizyon bilgisidir (ETag, VersionID, boyut, metadata).
+	Version() ObjectVersion
+	// Save, gövdeyi DownloadObject ile aynı staging ve doğrulama garantileriyle
+	// 'destinationPath' yoluna yazar ve SHA-256 özetini döndürür. İndirme
+	// yarıda kalıp tekrar istenirse bu istek Version().ETag'e sabitlenir; böylece
+	// kaydedilen baytlar her zaman kaydedilen ETag'e aittir.
+	Save(destinationPath, expectedSHA256 string) (string, error)
+	// Close, gövde okunmadıysa bağlantıyı kapatır.
+	Close() error
+}
+
+// stagedOpenObject, OpenObject arayüzünün açık bir yanıt gövdesini
+// stageResumable ile staging'e yazan ortak implementasyonudur.
+type stagedOpenObject struct {
+	obj        ObjectVersion
+	etag       string        // Yanıttaki ETag (stageResumable'ın yarım dosya kaydında saklanır)
+	body       io.ReadCloser // Henüz okunmamış gövde (kullanıldıktan sonra nil)
+	stagingDir string
+	ranged     rangeFetcher // Devam istekleri için; 'etag'e sabitlenmiş olmalıdır
+}
+
+func (o *stagedOpenObject) Version() ObjectVersion { return o.obj }
+
+func (o *stagedOpenObject) Save(destinationPath, expectedSHA256 string) (string, error) {
+	return stageResumable(o.stagingDir, destinationPath, expectedSHA256, func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		// Açık gövde sadece baştan indirmede ve aynı ETag için kullanılabilir;
+		// staging'de devam edilebilecek bir yarım dosya varsa ranged istek yapılır.
+		if o.body != nil && offset == 0 && (ifMatch == "" || ifMatch == o.etag) {
+			body := o.body
+			o.body = nil
+			return body, o.etag, o.obj.Size, nil
+		}
+		o.Close()
+		return o.ranged(offset, ifMatch)
+	})
+}
+
+func (o *stagedOpenObject) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+// conditionalGetEnabled, bu döngüde revizyon kontrolünün koşullu GET ile
+// yapılıp yapılamayacağını döndürür. Prefix modunda hangi anahtarın isteneceği
+// listelemeden önce bilinmez; sabitlenmiş bir revizyon ise hiç değişmez.
+func (p *Poller) conditionalGetEnabled() (ConditionalFetcher, bool) {
+	if !p.cfg.S3ConditionalGet || p.cfg.S3Prefix != "" || p.cfg.S3VersionID != "" {
+		return nil, false
+	}
+	fetcher, ok := p.s3.(ConditionalFetcher)
+	return fetcher, ok && p.GetStatus() != ""
+}
+
+// resolveRemoteConditional, uzak revizyonu tek bir koşullu GET ile kontrol eder.
+// Model değişmemişse (304) bilinen revizyon döndürülür ve gövde açılmaz.
+// Son görülen revizyon karantinadaysa istek onun ETag'i ile yapılır; aksi halde
+// karantinadaki sürüm yayında kaldıkça her döngüde gövdesi açılıp atılırdı.
+func (p *Poller) resolveRemoteConditional(fetcher ConditionalFetcher) (ObjectVersion, OpenObject, error) {
+	p.mu.RLock()
+	known := ObjectVersion{Key: p.cfg.S3Key, ETag: p.lastKnownETag, VersionID: p.lastKnownVersionID}
+	p.mu.RUnlock()
+	if last := p.lastRemote; last.ETag != "" && p.isQuarantined(last.ID()) {
+		known = last
+	}
+
+	opened, err := fetcher.GetIfChanged(p.cfg.S3Bucket, p.cfg.S3Key, known.ETag)
+	if errors.Is(err, ErrNotModified) {
+		return known, nil, nil
+	}
+	if err != nil {
+		return ObjectVersion{}, nil, fmt.Errorf("koşullu GET hatası: %w", err)
+	}
+	return opened.Version(), opened, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// MockConditionalS3Client, koşullu GET destekleyen bir S3 istemcisini taklit eder.
+type MockConditionalS3Client struct {
+	MockS3Client
+	Content          []byte
+	HeadCalls        int
+	ConditionalETags []string // GetIfChanged'e verilen ETag'ler
+	OpenedBodies     int      // 304 yerine gövdesi açılan istek sayısı
+}
+
+func (m *MockConditionalS3Client) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	m.HeadCalls++
+	return m.MockS3Client.HeadObject(bucket, key, versionID)
+}
+
+func (m *MockConditionalS3Client) GetIfChanged(bucket, key, etag string) (OpenObject, error) {
+	m.ConditionalETags = append(m.ConditionalETags, etag)
+	if etag == m.EtagToReturn {
+		return nil, ErrNotModified
+	}
+	m.OpenedBodies++
+	return &mockOpenObject{m: m, obj: ObjectVersion{Key: key, ETag: m.EtagToReturn, Size: int64(len(m.Content))}}, nil
+}
+
+type mockOpenObject struct {
+	m   *MockConditionalS3Client
+	obj ObjectVersion
+}
+
+func (o *mockOpenObject) Version() ObjectVersion { return o.obj }
+
+func (o *mockOpenObject) Save(destinationPath, expectedSHA256 string) (string, error) {
+	digest := sha256Hex(o.m.Content)
+	if err := verifySHA256(expectedSHA256, digest); err != nil {
+		return "", err
+	}
+	os.MkdirAll(filepath.Dir(destinationPath), 0o755)
+	return digest, os.WriteFile(destinationPath, o.m.Content, 0o644)
+}
+
+func (o *mockOpenObject) Close() error { return nil }
+
+// TestPoller_ConditionalGet, koşullu GET açıkken HeadObject yapılmadığını,
+// 304 yanıtının "değişiklik yok" sayıldığını ve değişen modelin aynı yanıttan
+// kaydedildiğini test eder.
+func TestPoller_ConditionalGet(t *testing.T) {
+	dir := t.TempDir()
+	mockS3 := &MockConditionalS3Client{MockS3Client: MockS3Client{EtagToReturn: "v1"}, Content: []byte("yeni model")}
+	mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", S3ConditionalGet: true}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+	mockDeploy := &MockDeployer{}
+	p := NewPoller(mockCfg, mockS3, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	// 1. Değişiklik yok (304).
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if len(mockDeploy.Calls) != 0 {
+		t.Errorf("Model değişmemişken dağıtım yapılmamalıydı: %v", mockDeploy.Calls)
+	}
+
+	// 2. Yeni model: aynı yanıttan indirilir ve yanıttaki ETag kaydedilir.
+	mockS3.EtagToReturn = "v2"
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if mockS3.HeadCalls != 0 {
+		t.Errorf("Koşullu GET açıkken HeadObject çağrılmamalıydı (%d çağrı)", mockS3.HeadCalls)
+	}
+	if len(mockS3.ConditionalETags) != 2 || mockS3.ConditionalETags[1] != "v1" {
+		t.Errorf("İstekler bilinen ETag ile yapılmalıydı, alınan: %v", mockS3.ConditionalETags)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, modelsSubdir, "model-v2.bin"))
+	if string(got) != "yeni model" || mockStore.State.ETag != "v2" {
+		t.Errorf("Yeni model kaydedilmeliydi (içerik: %q, ETag: %s)", got, mockStore.State.ETag)
+	}
+}
+
+// TestPoller_ConditionalGetQuarantined, karantinadaki bir revizyon yayında
+// kaldıkça her döngüde gövdesinin açılmadığını, yeni bir sürüm yayınlanınca
+// ise normal şekilde indirildiğini test eder.
+func TestPoller_ConditionalGetQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	mockS3 := &MockConditionalS3Client{MockS3Client: MockS3Client{EtagToReturn: "v2"}, Content: []byte("bozuk model")}
+	mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", S3ConditionalGet: true}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1", Quarantine: map[string]QuarantineEntry{"v2": {Failures: 100}}}}
+	mockDeploy := &MockDeployer{}
+	p := NewPoller(mockCfg, mockS3, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	for i := 0; i < 3; i++ {
+		if err := p.RunOnce(); err != nil {
+			t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+		}
+	}
+	// Sadece ilk döngü (karantinadaki revizyon henüz görülmemişken) gövde açar.
+	if mockS3.OpenedBodies != 1 || len(mockDeploy.Calls) != 0 {
+		t.Errorf("Karantinadaki sürüm için tek bir gövde açılmalıydı (açılan: %d, dağıtım: %v)", mockS3.OpenedBodies, mockDeploy.Calls)
+	}
+	if got := mockS3.ConditionalETags; len(got) != 3 || got[1] != "v2" || got[2] != "v2" {
+		t.Errorf("Sonraki istekler karantinadaki ETag ile yapılmalıydı, alınan: %v", got)
+	}
+
+	mockS3.EtagToReturn, mockS3.Content = "v3", []byte("düzeltilmiş model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if mockStore.State.ETag != "v3" {
+		t.Errorf("Yeni sürüm deploy edilmeliydi, durum: %+v", mockStore.State)
+	}
+}
+
+// TestRealS3Client_GetIfChanged, gerçek S3 istemcisinin 304 yanıtını
+// ErrNotModified olarak, 200 yanıtını ise ETag'i aynı yanıttan okunan bir
+// nesne olarak döndürdüğünü sahte bir S3 uç noktasıyla test eder.
+func TestRealS3Client_GetIfChanged(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	content := []byte("model ağırlıkları")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/prod/model.bin" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		if r.Header.Get("If-None-Match") == `"v2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("x-amz-meta-sha256", sha256Hex(content))
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	client, err := NewRealS3Client(&Config{S3Endpoint: server.URL, S3UsePathStyle: true, S3Region: "us-east-1"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealS3Client() hata döndürdü: %v", err)
+	}
+
+	if _, err := client.GetIfChanged("models", "prod/model.bin", "v2"); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("ErrNotModified bekleniyordu, alınan: %v", err)
+	}
+
+	opened, err := client.GetIfChanged("models", "prod/model.bin", "v1")
+	if err != nil {
+		t.Fatalf("GetIfChanged() hata döndürdü: %v", err)
+	}
+	defer opened.Close()
+	obj := opened.Version()
+	if obj.ETag != "v2" || obj.Metadata["sha256"] != sha256Hex(content) {
+		t.Errorf("Revizyon bilgisi yanıttan okunmalıydı, alınan: %+v", obj)
+	}
+	dest := filepath.Join(t.TempDir(), "model-v2.bin")
+	digest, err := opened.Save(dest, sha256Hex(content))
+	if err != nil {
+		t.Fatalf("Save() hata döndürdü: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if digest != sha256Hex(content) || string(got) != string(content) {
+		t.Errorf("Gövde hedefe yazılmalıydı (özet: %s)", digest)
+	}
+}
@@ -2,16 +2,170 @@ package main // Bu dosyanın, bir kütüphane değil, çalıştırılabilir bir
 
 import (
 	"encoding/json" // JSON verilerini okumak ve yazmak için (Adım 3)
-	"os"            // İşletim sistemi fonksiyonları için, örneğin dosya okuma (Adım 3)
+	"fmt"
+	"os" // İşletim sistemi fonksiyonları için, örneğin dosya okuma (Adım 3)
+	"path/filepath"
+	"time"
 )
 
 // Config (Yapı), bizim JSON yapılandırma dosyamızın Go dilindeki temsilcisidir.
 // 'json:"..."' etiketleri (tags), Go'daki (büyük harfli) alan adını
 // JSON dosyasındaki (küçük harfli) karşılığına eşler.
 type Config struct {
+	// Name, hedefin adıdır ve sadece 'targets' içinde kullanılır. Hedefin durum,
+	// staging ve model dizinleri bu adla ayrılır (sadece harf, rakam, '-', '_', '.').
+	Name string `json:"name"`
+	// LinkName, aktif model bağının adıdır. Göreli ise veri kök dizinine göredir.
+	// (varsayılan: "active_model_link", 'targets' içinde "<name>_model_link")
+	LinkName string `json:"link_name"`
+	// PollIntervalSeconds, kaynağın kaç saniyede bir kontrol edileceğidir (varsayılan: 60).
+	PollIntervalSeconds int `json:"poll_interval_seconds"`
+
+	// Targets, ajanın yönettiği modellerdir. Boşsa ajan üst düzey ayarlarla tek bir
+	// model yönetir. Her hedef, belirtmediği ayarları üst düzeyden devralır.
+	Targets []Config `json:"targets"`
+
 	S3Bucket         string `json:"s3_bucket"`
 	S3Key            string `json:"s3_key"`
 	DeployScriptPath string `json:"deploy_script_path"`
+
+	// S3VersionID, versiyonlama açık bir bucket'ta belirli bir revizyona
+	// sabitlemek (veya o revizyona geri dönmek) için kullanılır.
+	// Boş bırakılırsa her zaman en güncel revizyon izlenir.
+	S3VersionID string `json:"s3_version_id"`
+
+	// S3Prefix ayarlıysa tek bir anahtar yerine bu prefix altındaki nesneler
+	// listelenir ve en yeni sürüm deploy edilir (S3Key ve S3VersionID kullanılmaz).
+	// (örn: "models/" altında "models/v1.4.0/model.bin", "models/v1.5.0/model.bin")
+	S3Prefix string `json:"s3_prefix"`
+	// S3SelectBy, en yeni sürümün nasıl seçileceğidir: "semver" (varsayılan),
+	// "timestamp" veya "last_modified".
+	S3SelectBy string `json:"s3_select_by"`
+	// S3KeyPattern, prefix altındaki anahtarlardan modeli seçen düzenli ifadedir
+	// (prefix modunda zorunludur; yan dosyalar da sürüm numarası taşıyabilir).
+	// Bir yakalama grubu içeriyorsa sürüm bilgisi o gruptan okunur.
+	// (örn: "^models/(v[^/]+)/model\\.bin$")
+	S3KeyPattern string `json:"s3_key_pattern"`
+
+	// S3 uyumlu depolar (MinIO, Ceph RGW, şirket içi ağ geçitleri) için ayarlar.
+	// Hepsi isteğe bağlıdır; boş bırakılırsa AWS varsayılanları kullanılır.
+	S3Endpoint     string `json:"s3_endpoint"`       // Özel uç nokta (örn: "https://minio.local:9000")
+	S3UsePathStyle bool   `json:"s3_use_path_style"` // "bucket.host/key" yerine "host/bucket/key" adresleme
+	S3Region       string `json:"s3_region"`         // Bölge (MinIO için genellikle "us-east-1")
+	S3CABundle     string `json:"s3_ca_bundle"`      // Uç noktanın TLS sertifikası için PEM CA dosyası
+
+	// S3ConditionalGet, true ise her döngüde HeadObject + GetObject yerine tek bir
+	// 'If-None-Match' koşullu GetObject yapılır (304: değişiklik yok). İndirilen
+	// baytlar ve kaydedilen ETag aynı yanıttan gelir. Prefix modunda ve
+	// s3_version_id ile kullanılmaz.
+	S3ConditionalGet bool `json:"s3_conditional_get"`
+
+	// Source, modelin alındığı kaynaktır: "s3" (varsayılan), "http", "file" veya "azure".
+	// Hedef başına seçilebilir. "http" kaynağında model 'http.url' adresinden,
+	// "file" kaynağında 'file.path' dosyasından alınır; s3_key boş bırakılabilir
+	// veya bu adrese (dosyanın dizinine) göre çözülen göreli bir yol olabilir.
+	Source string `json:"source"`
+	// HTTP, "http" kaynağının adresi, kimlik bilgileri ve ek başlıklarıdır.
+	HTTP HTTPSourceConfig `json:"http"`
+	// File, "file" kaynağının izlediği dosya (NFS/SMB paylaşımı, USB bellek) ve
+	// revizyonun nasıl türetileceğidir.
+	File FileSourceConfig `json:"file"`
+	// Azure, "azure" kaynağının hesap ve kimlik bilgileridir. Container adı
+	// s3_bucket, blob adı s3_key (veya s3_prefix) ile verilir.
+	Azure AzureSourceConfig `json:"azure"`
+
+	// Bundle, true ise izlenen nesne tek bir model değil, birden fazla dosyayı
+	// (model, tokenizer, etiketler...) listeleyen bir JSON manifestodur. Dosyalar
+	// sürüme özel bir dizine indirilir ve aktif bağ bu dizini gösterir.
+	Bundle bool `json:"bundle"`
+	// BundleParallelism, paket dosyalarının aynı anda kaç tanesinin indirileceğidir (varsayılan: 4).
+	BundleParallelism int `json:"bundle_parallelism"`
+
+	// Archive, model bir arşiv (tar.gz, tar.zst, zip) olarak yayınlanıyorsa
+	// ayarlanır. Arşiv sürüme özel bir dizine açılır; aktif bağ ve `deploy.sh --test`
+	// bu dizini kullanır. Paket (bundle) modunda kullanılmaz.
+	Archive ArchiveConfig `json:"archive"`
+
+	// Delta, true ise yeni model tam olarak indirilmeden önce aktif modelden yeni
+	// modele giden yayınlanmış bir delta ('<key>.<aktif modelin sha256>.delta') aranır.
+	// Delta yoksa veya uygulanamazsa model tam olarak indirilir.
+	Delta bool `json:"delta"`
+
+	// ContentStore, true ise modeller 'store/' altındaki içerik adresli depoda
+	// (SHA-256 özetine göre) tutulur ve 'models/' altına sabit bağlantıyla yerleştirilir.
+	// Aynı içerik tekrar yayınlanırsa indirilmez; her sürümün kullandığı dosyalar kaydedilir.
+	ContentStore bool `json:"content_store"`
+
+	// Retention, 'models/' altındaki eski sürümlerin ne kadar tutulacağını belirler.
+	// Her başarılı dağıtımdan sonra ve isteğe bağlı olarak (POST /gc) uygulanır.
+	// Boş bırakılırsa eski sürümler silinmez.
+	Retention RetentionConfig `json:"retention"`
+
+	// Disk, indirmeden önce yapılan boş alan ve en büyük model boyutu kontrolleridir.
+	// Boyut, S3 HeadObject'in bildirdiği ContentLength'ten okunur.
+	Disk DiskConfig `json:"disk"`
+
+	// Notifications, S3 olay bildirimlerinin okunacağı SQS kuyruğudur. Ayarlanırsa
+	// model değiştiğinde beklemeden kontrol edilir; kaçırılan olaylar için
+	// poll_interval_seconds yerine seyrek bir yedek kontrol yapılır.
+	Notifications NotificationConfig `json:"notifications"`
+
+	// DataDir, ajanın veri kök dizinidir. Altında staging/, models/, state/
+	// dizinleri ve aktif model bağı bulunur. Boş bırakılırsa çalışma dizini kullanılır.
+	// (örn: "/var/lib/edgesync")
+	DataDir string `json:"data_dir"`
+
+	// RequireSHA256, true ise yayınlanmış bir SHA-256 özeti olmayan modeller
+	// deploy edilmez. false ise özet varsa doğrulanır, yoksa sadece uyarı verilir.
+	RequireSHA256 bool `json:"require_sha256"`
+
+	// SigningPublicKeys, model imzalarını doğrulamak için kullanılan base64
+	// kodlu ed25519 açık anahtarlarıdır. Boş değilse her model için geçerli bir
+	// '<key>.sig' imzası zorunludur; imzasız veya geçersiz imzalı modeller deploy edilmez.
+	SigningPublicKeys []string `json:"signing_public_keys"`
+
+	// Health, `--reload` sonrasında servisin gerçekten sağlıklı olup olmadığını
+	// doğrulayan kontrollerdir. Boş bırakılırsa sağlık kontrolü yapılmaz.
+	Health HealthConfig `json:"health"`
+
+	// Soak, yeni modelin aktif edildikten sonra "stabil" sayılmadan önce
+	// izleneceği süreyi belirler. Boş bırakılırsa model hemen stabil sayılır.
+	Soak SoakConfig `json:"soak"`
+
+	// QuarantineThreshold, bir model versiyonunun (ETag) kaç başarısız denemeden
+	// sonra karantinaya alınıp atlanacağını belirler (varsayılan: 1).
+	QuarantineThreshold int `json:"quarantine_threshold"`
+}
+
+// SoakConfig, dağıtım sonrası izleme (soak) penceresini belirler.
+// Pencere boyunca her döngüde sağlık kontrolleri tekrar çalıştırılır ve
+// (MaxErrorRate > 0 ise) `deploy.sh --error-rate` çıktısı okunur.
+type SoakConfig struct {
+	DurationSeconds int     `json:"duration_seconds"` // İzleme süresi (örn: 900 = 15 dakika)
+	MaxErrorRate    float64 `json:"max_error_rate"`   // İzin verilen en yüksek hata oranı (0.0 - 1.0)
+}
+
+// HealthConfig, reload sonrası sağlık kontrollerinin nasıl çalışacağını belirler.
+type HealthConfig struct {
+	Checks          []HealthCheck `json:"checks"`
+	Retries         int           `json:"retries"`          // Toplam deneme sayısı (varsayılan: 3)
+	IntervalSeconds int           `json:"interval_seconds"` // Denemeler arası bekleme (varsayılan: 5)
+	DeadlineSeconds int           `json:"deadline_seconds"` // Tüm denemeler için üst süre sınırı (varsayılan: 60)
+}
+
+// HealthCheck, tek bir sağlık kontrolünü tanımlar.
+// Type alanına göre ilgili diğer alanlar kullanılır:
+//   - "http": URL, ExpectedStatus (varsayılan: 200), ExpectedBody (gövdede aranacak metin)
+//   - "tcp":  Address (örn: "localhost:9000")
+//   - "exec": Command (örn: ["./check.sh", "--quick"]), 'exit code 0' başarılı sayılır
+type HealthCheck struct {
+	Type           string   `json:"type"`
+	URL            string   `json:"url"`
+	ExpectedStatus int      `json:"expected_status"`
+	ExpectedBody   string   `json:"expected_body"`
+	Address        string   `json:"address"`
+	Command        []string `json:"command"`
+	TimeoutSeconds int      `json:"timeout_seconds"` // Tek bir kontrolün süre sınırı (varsayılan: 5)
 }
 
 // LoadConfig, belirtilen yoldan (path) bir JSON yapılandırma dosyası okur
@@ -37,6 +191,135 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	// 4. Adım: Her şey başarılıysa, yapılandırmayı (cfg) ve nil (hata yok) döndür.
+	// 4. Adım: Birden fazla model tanımlanmışsa hedefleri üst düzey ayarlarla birleştir.
+	if len(cfg.Targets) > 0 {
+		if err := resolveTargets(&cfg, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Adım: Her şey başarılıysa, yapılandırmayı (cfg) ve nil (hata yok) döndür.
 	return &cfg, nil
 }
+
+// resolveTargets, her hedefi üst düzey ayarların bir kopyası üzerine açar;
+// böylece hedef sadece farklı olan alanları (kaynak, script, bağ adı...) belirtir.
+// Hedef adlarının ve bağ adlarının benzersiz olduğu da burada doğrulanır.
+func resolveTargets(cfg *Config, data []byte) error {
+	var raw struct {
+		Targets []json.RawMessage `json:"targets"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	base := *cfg
+	base.Name, base.LinkName, base.Targets = "", "", nil
+	// JSON üzerinden kopyalamak, dilimlerin (health.checks vb.) hedefler arasında
+	// paylaşılmamasını sağlar.
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	links := make(map[string]bool)
+	for i, r := range raw.Targets {
+		var t Config
+		if err := json.Unmarshal(baseJSON, &t); err != nil {
+			return err
+		}
+		// json.Unmarshal dolu bir map'in üzerine yazmaz, anahtarları ekler. Dilimler
+		// gibi map'ler de hedefte belirtilmişse üst düzeydekinin yerini almalıdır.
+		var overrides struct {
+			HTTP struct {
+				Headers json.RawMessage `json:"headers"`
+			} `json:"http"`
+		}
+		if err := json.Unmarshal(r, &overrides); err != nil {
+			return fmt.Errorf("targets[%d]: %w", i, err)
+		}
+		if overrides.HTTP.Headers != nil {
+			t.HTTP.Headers = nil
+		}
+		if err := json.Unmarshal(r, &t); err != nil {
+			return fmt.Errorf("targets[%d]: %w", i, err)
+		}
+
+		switch {
+		case t.Name == "":
+			return fmt.Errorf("targets[%d]: 'name' zorunludur", i)
+		case fileSafe(t.Name) != t.Name || t.Name == "." || t.Name == "..":
+			return fmt.Errorf("targets[%d]: geçersiz hedef adı '%s' (sadece harf, rakam, '-', '_', '.')", i, t.Name)
+		case names[t.Name]:
+			return fmt.Errorf("targets[%d]: '%s' adı birden fazla hedefte kullanılmış", i, t.Name)
+		case len(t.Targets) > 0:
+			return fmt.Errorf("targets[%d]: hedefler iç içe tanımlanamaz", i)
+		}
+		link := t.ActiveLinkPath()
+		if links[link] {
+			return fmt.Errorf("targets[%d]: '%s' bağı birden fazla hedefte kullanılmış", i, link)
+		}
+		names[t.Name], links[link] = true, true
+		cfg.Targets[i] = t
+	}
+	return nil
+}
+
+// TargetConfigs, ajanın yönetmesi gereken her model için bir yapılandırma döndürür.
+// 'targets' boşsa üst düzey yapılandırmanın kendisi tek hedeftir.
+func (c *Config) TargetConfigs() []*Config {
+	if len(c.Targets) == 0 {
+		return []*Config{c}
+	}
+	targets := make([]*Config, len(c.Targets))
+	for i := range c.Targets {
+		targets[i] = &c.Targets[i]
+	}
+	return targets
+}
+
+// ActiveLinkPath, aktif model bağının yolunu döndürür. Göreli bağ adları
+// veri kök dizinine göre çözülür.
+func (c *Config) ActiveLinkPath() string {
+	name := c.LinkName
+	if name == "" {
+		name = activeLinkName
+		if c.Name != "" {
+			name = c.Name + "_model_link"
+		}
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.DataRoot(), name)
+}
+
+// PollInterval, kaynağın kontrol aralığını döndürür (varsayılan: 60 saniye).
+func (c *Config) PollInterval() time.Duration {
+	if c.Notifications.Enabled() {
+		// Değişiklikler bildirimle gelir; düzenli kontrol sadece yedektir.
+		if c.Notifications.FallbackPollSeconds <= 0 {
+			return defaultFallbackPoll
+		}
+		return time.Duration(c.Notifications.FallbackPollSeconds) * time.Second
+	}
+	return c.regularPollInterval()
+}
+
+// regularPollInterval, bildirimlerden bağımsız düzenli kontrol aralığını döndürür.
+func (c *Config) regularPollInterval() time.Duration {
+	if c.PollIntervalSeconds <= 0 {
+		return pollInterval
+	}
+	return time.Duration(c.PollIntervalSeconds) * time.Second
+}
+
+// DataRoot, veri kök dizinini döndürür. 'data_dir' ayarlanmamışsa
+// geriye dönük uyumluluk için çalışma dizinini (".") kullanır.
+func (c *Config) DataRoot() string {
+	if c.DataDir == "" {
+		return "."
+	}
+	return c.DataDir
+}
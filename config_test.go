@@ -1,8 +1,10 @@
 package main // Testler de ana paketimizin bir parçasıdır.
 
 import (
-	"os"      // Test için sahte dosya oluşturmak/silmek için
+	"os" // Test için sahte dosya oluşturmak/silmek için
+	"path/filepath"
 	"testing" // Go'nun test kütüphanesi
+	"time"
 )
 
 // TestLoadConfig, bizim LoadConfig fonksiyonumuzun doğru çalışıp çalışmadığını test eder.
@@ -58,3 +60,74 @@ func TestLoadConfig(t *testing.T) {
 		t.Errorf("S3Key için beklenen değer '%s', ancak alınan değer '%s'", beklenenKey, cfg.S3Key)
 	}
 }
+
+// TestLoadConfig_Targets, hedeflerin üst düzey ayarları devraldığını ve
+// varsayılan bağ adlarının hedef adından türetildiğini test eder.
+func TestLoadConfig_Targets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{
+		"s3_bucket": "ortak-bucket",
+		"deploy_script_path": "/test/deploy.sh",
+		"data_dir": "/var/lib/edgesync",
+		"health": {"checks": [{"type": "tcp", "address": "localhost:9000"}]},
+		"http": {"headers": {"Authorization": "Bearer ortak", "X-Cihaz": "kamera-1"}},
+		"targets": [
+			{"name": "detector", "s3_key": "detector/model.bin", "poll_interval_seconds": 30},
+			{"name": "classifier", "s3_key": "classifier/model.onnx", "link_name": "/opt/classifier/model",
+			 "deploy_script_path": "/test/classifier.sh", "health": {"checks": []},
+			 "http": {"headers": {"X-Model": "classifier"}}}
+		]
+	}`), 0644)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig beklenmedik bir hata döndürdü: %v", err)
+	}
+	targets := cfg.TargetConfigs()
+	if len(targets) != 2 {
+		t.Fatalf("2 hedef bekleniyordu, %d alındı", len(targets))
+	}
+
+	detector, classifier := targets[0], targets[1]
+	if detector.S3Bucket != "ortak-bucket" || detector.DeployScriptPath != "/test/deploy.sh" || len(detector.Health.Checks) != 1 {
+		t.Errorf("Hedef üst düzey ayarları devralmalıydı: %+v", detector)
+	}
+	if detector.ActiveLinkPath() != filepath.Join("/var/lib/edgesync", "detector_model_link") {
+		t.Errorf("Beklenmedik bağ yolu: %s", detector.ActiveLinkPath())
+	}
+	if detector.PollInterval() != 30*time.Second {
+		t.Errorf("Beklenmedik kontrol aralığı: %v", detector.PollInterval())
+	}
+	if classifier.DeployScriptPath != "/test/classifier.sh" || len(classifier.Health.Checks) != 0 {
+		t.Errorf("Hedefin kendi ayarları üst düzey ayarları ezmeliydi: %+v", classifier)
+	}
+	if len(detector.HTTP.Headers) != 2 || detector.HTTP.Headers["Authorization"] != "Bearer ortak" {
+		t.Errorf("Hedef üst düzey başlıkları devralmalıydı: %v", detector.HTTP.Headers)
+	}
+	// Başlıklar birleştirilmez; hedefin başlıkları üst düzeydekilerin yerini alır.
+	if len(classifier.HTTP.Headers) != 1 || classifier.HTTP.Headers["X-Model"] != "classifier" {
+		t.Errorf("Hedefin başlıkları üst düzey başlıkların yerini almalıydı: %v", classifier.HTTP.Headers)
+	}
+	if classifier.ActiveLinkPath() != "/opt/classifier/model" || classifier.PollInterval() != pollInterval {
+		t.Errorf("Beklenmedik bağ yolu veya aralık: %s, %v", classifier.ActiveLinkPath(), classifier.PollInterval())
+	}
+}
+
+// TestLoadConfig_TargetValidation, hatalı hedef tanımlarının reddedildiğini test eder.
+func TestLoadConfig_TargetValidation(t *testing.T) {
+	tests := map[string]string{
+		"ad yok":       `{"targets": [{"s3_key": "a"}]}`,
+		"geçersiz ad":  `{"targets": [{"name": "../a"}]}`,
+		"tekrar eden":  `{"targets": [{"name": "a"}, {"name": "a"}]}`,
+		"aynı bağ adı": `{"targets": [{"name": "a", "link_name": "model"}, {"name": "b", "link_name": "model"}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			os.WriteFile(path, []byte(content), 0644)
+			if _, err := LoadConfig(path); err == nil {
+				t.Error("LoadConfig hata döndürmeliydi")
+			}
+		})
+	}
+}
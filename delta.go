@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Delta dosya biçimi (tamamı isteğe bağlı olarak zstd ile sıkıştırılmış, sayılar big-endian):
+//
+//	"EDGDLT01" | kaynak SHA-256 (32 bayt) | hedef SHA-256 (32 bayt) | hedef boyutu (uint64)
+//	ardından işlemler:
+//	  0x01 kopyala: konum (uint64), uzunluk (uint64)  -> eski dosyadan
+//	  0x02 ekle:    uzunluk (uint64), veri            -> deltanın içinden
+//	  0x00 son
+const (
+	deltaMagic     = "EDGDLT01"
+	deltaSuffix    = ".delta"
+	deltaBlockSize = 64 << 10 // Eşleşme aranan blok boyutu (makeDelta)
+	deltaMaxAdd    = 4 << 20  // Tek bir 'ekle' işleminin en büyük boyutu (makeDelta)
+
+	opEnd  byte = 0x00
+	opCopy byte = 0x01
+	opAdd  byte = 0x02
+)
+
+// errDeltaMismatch, delta başka bir kaynak veya hedef sürüm için üretilmişse döndürülür.
+var errDeltaMismatch = errors.New("delta bu sürümler için değil")
+
+// deltaKey, 'fromSHA256' özetli sürümden 'key' anahtarındaki sürüme giden
+// deltanın anahtarını döndürür. (örn: "prod/model.bin.<kaynak özet>.delta")
+func deltaKey(key, fromSHA256 string) string {
+	return fmt.Sprintf("%s.%s%s", key, fromSHA256, deltaSuffix)
+}
+
+// downloadModel, yeni modeli 'dest' yoluna getirir. Delta açıksa önce aktif
+// modelden yeni modele giden yayınlanmış bir delta aranır ve yerelde uygulanır;
+// uygun bir delta yoksa veya uygulanamazsa tam indirmeye geçilir.
+// İçerik deposu açıksa ve aynı içerik zaten yerelde varsa hiçbir şey indirilmez;
+// indirilen dosya depoya eklenir. Koşullu GET ile gövdesi zaten açılmış bir
+// nesne ('opened') varsa delta aranmaz, gövde doğrudan kaydedilir.
+func (p *Poller) downloadModel(remote ObjectVersion, opened OpenObject, oldTarget, dest, expectedSHA256 string) (string, error) {
+	if p.fetchFromStore(expectedSHA256, dest) {
+		return expectedSHA256, nil
+	}
+	if opened != nil {
+		digest, err := opened.Save(dest, expectedSHA256)
+		if err != nil {
+			return "", err
+		}
+		p.ingest(dest, digest)
+		return digest, nil
+	}
+	if p.cfg.Delta && !p.cfg.Bundle {
+		digest, err := p.applyPublishedDelta(remote, oldTarget, dest, expectedSHA256)
+		if err == nil {
+			p.ingest(dest, digest)
+			return digest, nil
+		}
+		p.log.Printf("[Delta] Delta kullanılamadı, tam indirmeye geçiliyor: %v", err)
+	}
+	digest, err := p.s3.DownloadObject(p.cfg.S3Bucket, remote, dest, expectedSHA256)
+	if err != nil {
+		return "", err
+	}
+	p.ingest(dest, digest)
+	return digest, nil
+}
+
+// applyPublishedDelta, aktif modelin özetine göre yayınlanmış deltayı indirir
+// ve aktif model dosyasına uygulayarak yeni modeli 'dest' yoluna yazar.
+func (p *Poller) applyPublishedDelta(remote ObjectVersion, oldTarget, dest, expectedSHA256 string) (string, error) {
+	p.mu.RLock()
+	fromSHA256 := p.deployedSHA256
+	p.mu.RUnlock()
+	if fromSHA256 == "" || expectedSHA256 == "" {
+		return "", errors.New("aktif veya yeni modelin SHA-256 özeti bilinmiyor")
+	}
+	oldTarget = p.resolveLinkTarget(oldTarget)
+	if info, err := os.Stat(oldTarget); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("aktif model dosyası okunamıyor (%s)", oldTarget)
+	}
+
+	key := deltaKey(remote.Key, fromSHA256)
+	obj, err := p.s3.HeadObject(p.cfg.S3Bucket, key, "")
+	if err != nil {
+		return "", err
+	}
+	// Delta ve oluşturulacak model aynı anda diskte durur; ikisine de yer olmalı.
+	if err := p.ensureDiskSpace(obj.Size, filepath.Dir(dest)); err != nil {
+		return "", err
+	}
+	deltaPath := dest + deltaSuffix
+	defer os.Remove(deltaPath)
+	if _, err := p.s3.DownloadObject(p.cfg.S3Bucket, obj, deltaPath, ""); err != nil {
+		return "", err
+	}
+
+	// Hedef boyutu deltanın (doğrulanmamış) başlığından okunur; yayınlanan
+	// boyutla ve model boyutu sınırıyla karşılaştırılmadan yazmaya başlanmaz.
+	reserve := func(size int64) error {
+		if remote.Size > 0 && size != remote.Size {
+			return fmt.Errorf("%w: delta sonucu %d bayt, yayınlanan model %d bayt", errDeltaMismatch, size, remote.Size)
+		}
+		return p.ensureDiskSpace(size, filepath.Dir(dest))
+	}
+
+	// Deltanın kendisinin özeti yayınlanmaz; sonuç dosyası hedef özetle doğrulanır.
+	digest, err := applyDelta(oldTarget, deltaPath, dest, fromSHA256, expectedSHA256, reserve)
+	if err != nil {
+		return "", err
+	}
+	p.log.Printf("[Delta] Yeni model delta ile oluşturuldu (%d bayt delta, tam model %d bayt).", obj.Size, remote.Size)
+	return digest, nil
+}
+
+// applyDelta, 'deltaPath'teki deltayı 'oldPath' dosyasına uygular ve sonucu
+// 'dest' yoluna atomik olarak yazar. Delta başka sürümler için üretilmişse
+// errDeltaMismatch, sonuç 'toSHA256' ile eşleşmezse ErrChecksumMismatch döner.
+// 'reserve' verilmişse başlıktaki hedef boyutuyla yazmadan önce çağrılır; hata
+// döndürürse delta uygulanmaz.
+func applyDelta(oldPath, deltaPath, dest, fromSHA256, toSHA256 string, reserve func(size int64) error) (string, error) {
+	df, err := os.Open(deltaPath)
+	if err != nil {
+		return "", err
+	}
+	defer df.Close()
+	r, closeReader, err := openDeltaStream(df)
+	if err != nil {
+		return "", err
+	}
+	defer closeReader()
+
+	header := make([]byte, len(deltaMagic)+2*sha256.Size+8)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(deltaMagic)]) != deltaMagic {
+		return "", errors.New("delta başlığı okunamadı")
+	}
+	from := hex.EncodeToString(header[len(deltaMagic) : len(deltaMagic)+sha256.Size])
+	to := hex.EncodeToString(header[len(deltaMagic)+sha256.Size : len(deltaMagic)+2*sha256.Size])
+	size := binary.BigEndian.Uint64(header[len(header)-8:])
+	if from != fromSHA256 || to != toSHA256 {
+		return "", fmt.Errorf("%w: %s -> %s", errDeltaMismatch, from, to)
+	}
+	if size > math.MaxInt64 {
+		return "", fmt.Errorf("delta hedef boyutu geçersiz: %d bayt", size)
+	}
+	if reserve != nil {
+		if err := reserve(int64(size)); err != nil {
+			return "", err
+		}
+	}
+
+	old, err := os.Open(oldPath)
+	if err != nil {
+		return "", err
+	}
+	defer old.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+	tmpPath := dest + tmpDirSuffix
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpPath) // Başarılı olursa zaten yeniden adlandırılmıştır.
+	defer out.Close()
+
+	hasher := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(out, hasher))
+	var written uint64
+	var buf [16]byte
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("delta okunamadı: %w", err)
+		}
+		if op == opEnd {
+			break
+		}
+
+		var n int64
+		switch op {
+		case opCopy:
+			if _, err := io.ReadFull(r, buf[:16]); err != nil {
+				return "", fmt.Errorf("delta okunamadı: %w", err)
+			}
+			offset, length := binary.BigEndian.Uint64(buf[:8]), binary.BigEndian.Uint64(buf[8:16])
+			if length > size-written {
+				return "", errors.New("delta hedef boyutu aşıyor")
+			}
+			n, err = io.Copy(bw, io.NewSectionReader(old, int64(offset), int64(length)))
+			if err == nil && uint64(n) != length {
+				err = errors.New("kopyalanan bölge eski dosyanın dışında")
+			}
+		case opAdd:
+			if _, err := io.ReadFull(r, buf[:8]); err != nil {
+				return "", fmt.Errorf("delta okunamadı: %w", err)
+			}
+			length := binary.BigEndian.Uint64(buf[:8])
+			if length > size-written {
+				return "", errors.New("delta hedef boyutu aşıyor")
+			}
+			n, err = io.CopyN(bw, r, int64(length))
+		default:
+			return "", fmt.Errorf("bilinmeyen delta işlemi: 0x%02x", op)
+		}
+		if err != nil {
+			return "", fmt.Errorf("delta uygulanamadı: %w", err)
+		}
+		written += uint64(n)
+	}
+	if written != size {
+		return "", fmt.Errorf("delta sonucu %d bayt, beklenen %d", written, size)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	if err := verifySHA256(toSHA256, digest); err != nil {
+		return "", err
+	}
+	if err := out.Sync(); err != nil {
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, dest); err != nil {
+		return "", err
+	}
+	syncDir(filepath.Dir(dest))
+	return digest, nil
+}
+
+// openDeltaStream, deltayı okumak için bir akış açar; zstd ile sıkıştırılmışsa açar.
+func openDeltaStream(f io.Reader) (*bufio.Reader, func(), error) {
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(4)
+	if !bytes.Equal(head, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		return br, func() {}, nil
+	}
+	zr, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bufio.NewReader(zr), zr.Close, nil
+}
+
+// makeDelta, 'oldPath'ten 'newPath'e giden bir delta üretir ve zstd ile
+// sıkıştırarak 'w'ye yazar. Yeni dosya sabit boyutlu bloklara bölünür; eski
+// dosyada aynı içerikli bir blok varsa kopyalanır, yoksa veri deltaya eklenir.
+// (İnce ayar gibi yerinde değişikliklerde delta, değişen bloklar kadardır.)
+func makeDelta(oldPath, newPath string, w io.Writer) error {
+	fromSHA256, err := fileSHA256(oldPath)
+	if err != nil {
+		return err
+	}
+	toSHA256, err := fileSHA256(newPath)
+	if err != nil {
+		return err
+	}
+	newInfo, err := os.Stat(newPath)
+	if err != nil {
+		return err
+	}
+
+	// Eski dosyanın bloklarını indeksle.
+	index := make(map[[sha256.Size]byte]int64)
+	old, err := os.Open(oldPath)
+	if err != nil {
+		return err
+	}
+	defer old.Close()
+	block := make([]byte, deltaBlockSize)
+	for offset := int64(0); ; offset += deltaBlockSize {
+		n, err := io.ReadFull(old, block)
+		if n == deltaBlockSize {
+			sum := sha256.Sum256(block)
+			if _, ok := index[sum]; !ok {
+				index[sum] = offset
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	dw := &deltaWriter{w: bufio.NewWriter(zw), copyOffset: -1}
+	from, _ := hex.DecodeString(fromSHA256)
+	to, _ := hex.DecodeString(toSHA256)
+	dw.w.WriteString(deltaMagic)
+	dw.w.Write(from)
+	dw.w.Write(to)
+	dw.uint64(uint64(newInfo.Size()))
+
+	nf, err := os.Open(newPath)
+	if err != nil {
+		return err
+	}
+	defer nf.Close()
+	for {
+		n, err := io.ReadFull(nf, block)
+		if n > 0 {
+			if off, ok := index[sha256.Sum256(block[:n])]; ok && n == deltaBlockSize {
+				dw.copy(off, int64(n))
+			} else {
+				dw.add(block[:n])
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	dw.flushCopy()
+	dw.flushAdd()
+	dw.w.WriteByte(opEnd)
+	if err := dw.w.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// deltaWriter, ardışık kopyalama ve ekleme işlemlerini birleştirerek yazar.
+type deltaWriter struct {
+	w          *bufio.Writer
+	pendingAdd []byte
+	copyOffset int64
+	copyLength int64
+}
+
+func (dw *deltaWriter) uint64(v uint64) {
+	binary.Write(dw.w, binary.BigEndian, v)
+}
+
+// copy, eski dosyadaki [off, off+n) bölgesini kopyalama işlemi olarak ekler.
+// Bir önceki kopyalamanın devamıysa onunla birleştirilir.
+func (dw *deltaWriter) copy(off, n int64) {
+	dw.flushAdd()
+	if dw.copyLength > 0 && dw.copyOffset+dw.copyLength == off {
+		dw.copyLength += n
+		return
+	}
+	dw.flushCopy()
+	dw.copyOffset, dw.copyLength = off, n
+}
+
+// add, veriyi deltaya eklenecek verilere ekler.
+func (dw *deltaWriter) add(data []byte) {
+	dw.flushCopy()
+	dw.pendingAdd = append(dw.pendingAdd, data...)
+	if len(dw.pendingAdd) >= deltaMaxAdd {
+		dw.flushAdd()
+	}
+}
+
+func (dw *deltaWriter) flushCopy() {
+	if dw.copyLength > 0 {
+		dw.w.WriteByte(opCopy)
+		dw.uint64(uint64(dw.copyOffset))
+		dw.uint64(uint64(dw.copyLength))
+	}
+	dw.copyOffset, dw.copyLength = -1, 0
+}
+
+func (dw *deltaWriter) flushAdd() {
+	if len(dw.pendingAdd) > 0 {
+		dw.w.WriteByte(opAdd)
+		dw.uint64(uint64(len(dw.pendingAdd)))
+		dw.w.Write(dw.pendingAdd)
+	}
+	dw.pendingAdd = dw.pendingAdd[:0]
+}
+
+// runMakeDelta, `edgesync-agent make-delta <eski> <yeni> <çıktı>` komutunu çalıştırır.
+// Yayın tarafında (CI) deltaları üretmek için kullanılır.
+func runMakeDelta(args []string) error {
+	if len(args) != 3 {
+		return errors.New("kullanım: edgesync-agent make-delta <eski model> <yeni model> <çıktı>")
+	}
+	out, err := os.Create(args[2])
+	if err != nil {
+		return err
+	}
+	if err := makeDelta(args[0], args[1], out); err != nil {
+		out.Close()
+		os.Remove(args[2])
+		return err
+	}
+	return out.Close()
+}
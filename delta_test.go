@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeDeltaFixture, bir eski model ve ondan türetilmiş (bir bloğu değişmiş,
+// sonuna veri eklenmiş) yeni bir model üretir.
+func writeDeltaFixture(t *testing.T, dir string) (oldPath, newPath string, newContent []byte) {
+	t.Helper()
+	old := make([]byte, 5*deltaBlockSize+123)
+	rand.New(rand.NewSource(1)).Read(old)
+	newContent = append([]byte(nil), old...)
+	copy(newContent[2*deltaBlockSize+10:], []byte("ince ayar"))
+	newContent = append(newContent, []byte("yeni katman")...)
+
+	oldPath, newPath = filepath.Join(dir, "old.bin"), filepath.Join(dir, "new.bin")
+	os.WriteFile(oldPath, old, 0o644)
+	os.WriteFile(newPath, newContent, 0o644)
+	return oldPath, newPath, newContent
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath, newContent := writeDeltaFixture(t, dir)
+
+	var delta bytes.Buffer
+	if err := makeDelta(oldPath, newPath, &delta); err != nil {
+		t.Fatalf("makeDelta() hata döndürdü: %v", err)
+	}
+	if delta.Len() > 2*deltaBlockSize {
+		t.Errorf("Delta çok büyük (%d bayt); sadece değişen bloklar kadar olmalıydı", delta.Len())
+	}
+	deltaPath := filepath.Join(dir, "model.delta")
+	os.WriteFile(deltaPath, delta.Bytes(), 0o644)
+
+	fromSHA256, _ := fileSHA256(oldPath)
+	toSHA256 := sha256Hex(newContent)
+	dest := filepath.Join(dir, "models", "model-v2.bin")
+	digest, err := applyDelta(oldPath, deltaPath, dest, fromSHA256, toSHA256, nil)
+	if err != nil {
+		t.Fatalf("applyDelta() hata döndürdü: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if digest != toSHA256 || !bytes.Equal(got, newContent) {
+		t.Error("Delta uygulanarak oluşturulan dosya yeni modelle aynı olmalıydı")
+	}
+
+	// Başka bir kaynak sürüm için üretilmiş delta reddedilmeli.
+	if _, err := applyDelta(oldPath, deltaPath, dest, toSHA256, toSHA256, nil); !errors.Is(err, errDeltaMismatch) {
+		t.Errorf("errDeltaMismatch bekleniyordu, alınan: %v", err)
+	}
+
+	// Başlıktaki hedef boyutu yazmadan önce 'reserve' ile onaylanmalı.
+	os.Remove(dest)
+	var reserved int64
+	_, err = applyDelta(oldPath, deltaPath, dest, fromSHA256, toSHA256, func(size int64) error {
+		reserved = size
+		return ErrInsufficientSpace
+	})
+	if !errors.Is(err, ErrInsufficientSpace) || reserved != int64(len(newContent)) {
+		t.Errorf("ErrInsufficientSpace ve %d bayt bekleniyordu, alınan: %v, %d", len(newContent), err, reserved)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Reddedilen delta dosya oluşturmamalıydı")
+	}
+}
+
+// TestPoller_Delta, yayınlanmış bir delta varsa modelin tam indirilmeden
+// aktif modelden oluşturulduğunu, yoksa tam indirmeye geçildiğini test eder.
+func TestPoller_Delta(t *testing.T) {
+	for _, withDelta := range []bool{true, false} {
+		dir := t.TempDir()
+		oldPath, newPath, newContent := writeDeltaFixture(t, dir)
+		fromSHA256, _ := fileSHA256(oldPath)
+		toSHA256 := sha256Hex(newContent)
+
+		mockS3 := &MockS3Client{EtagToReturn: "v2", ExpectedSHA256ToReturn: toSHA256, DigestToReturn: toSHA256, Objects: map[string][]byte{}}
+		if withDelta {
+			var delta bytes.Buffer
+			makeDelta(oldPath, newPath, &delta)
+			mockS3.Objects[deltaKey("prod/model.bin", fromSHA256)] = delta.Bytes()
+		}
+		mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Delta: true}
+		mockStore := &MockStateStore{State: &AgentState{ETag: "v1", SHA256: fromSHA256}}
+
+		p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{CurrentTarget: oldPath}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+		if err := p.RunOnce(); err != nil {
+			t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+		}
+		if mockStore.State.SHA256 != toSHA256 {
+			t.Errorf("Yeni modelin özeti kaydedilmeliydi, alınan: %s", mockStore.State.SHA256)
+		}
+
+		modelPath := filepath.Join(dir, modelsSubdir, "model-v2.bin")
+		_, statErr := os.Stat(modelPath)
+		if withDelta {
+			// Sadece delta indirilmeli; model yerelde oluşturulmalı.
+			got, _ := os.ReadFile(modelPath)
+			if mockS3.DownloadCalls != 1 || !bytes.Equal(got, newContent) {
+				t.Errorf("Model delta ile oluşturulmalıydı (indirme sayısı: %d)", mockS3.DownloadCalls)
+			}
+		} else if mockS3.DownloadCalls != 1 || !os.IsNotExist(statErr) {
+			// Sahte istemci dosyayı yazmaz; tam indirme yolu kullanılmış olmalı.
+			t.Errorf("Delta yokken tam indirme yapılmalıydı (indirme sayısı: %d)", mockS3.DownloadCalls)
+		}
+	}
+}
+
+// TestPoller_DeltaRelativeDataDir, göreli bir 'data_dir' ile aktif modelin
+// (bağ mutlak veya bağın dizinine göre göreli olsun) delta tabanı olarak
+// bulunduğunu test eder.
+func TestPoller_DeltaRelativeDataDir(t *testing.T) {
+	for _, relative := range []bool{false, true} {
+		t.Chdir(t.TempDir())
+		os.MkdirAll(filepath.Join("data", modelsSubdir), 0o755)
+		oldPath, newPath, newContent := writeDeltaFixture(t, "data")
+		fromSHA256, _ := fileSHA256(oldPath)
+		toSHA256 := sha256Hex(newContent)
+		var delta bytes.Buffer
+		makeDelta(oldPath, newPath, &delta)
+
+		mockS3 := &MockS3Client{EtagToReturn: "v2", ExpectedSHA256ToReturn: toSHA256, DigestToReturn: toSHA256,
+			Objects: map[string][]byte{deltaKey("prod/model.bin", fromSHA256): delta.Bytes()}}
+		mockCfg := &Config{DataDir: "data", S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Delta: true}
+		mockStore := &MockStateStore{State: &AgentState{ETag: "v1", SHA256: fromSHA256}}
+
+		link := mockCfg.ActiveLinkPath()
+		target, _ := filepath.Abs(oldPath)
+		if relative {
+			target = "old.bin" // Bağın bulunduğu 'data/' dizinine göre.
+		}
+		linker := &RealLinker{}
+		linker.Set(target, link)
+
+		p := NewPoller(mockCfg, mockS3, &MockDeployer{}, linker, mockStore, &MockHealthProber{}, link)
+		if err := p.RunOnce(); err != nil {
+			t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+		}
+		got, err := os.ReadFile(link)
+		if mockS3.DownloadCalls != 1 || err != nil || !bytes.Equal(got, newContent) {
+			t.Errorf("Model delta ile oluşturulup bağlanmalıydı (göreli: %v, indirme sayısı: %d, hata: %v)", relative, mockS3.DownloadCalls, err)
+		}
+	}
+}
+
+// TestPoller_DeltaTooLarge, delta başlığındaki hedef boyutu model boyutu
+// sınırını aştığında deltanın uygulanmadığını ve tam indirmeye geçildiğini test eder.
+func TestPoller_DeltaTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath, newContent := writeDeltaFixture(t, dir)
+	fromSHA256, _ := fileSHA256(oldPath)
+	toSHA256 := sha256Hex(newContent)
+
+	var delta bytes.Buffer
+	makeDelta(oldPath, newPath, &delta)
+	mockS3 := &MockS3Client{EtagToReturn: "v2", ExpectedSHA256ToReturn: toSHA256, DigestToReturn: toSHA256,
+		Objects: map[string][]byte{deltaKey("prod/model.bin", fromSHA256): delta.Bytes()}}
+	mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Delta: true,
+		Disk: DiskConfig{MaxModelBytes: int64(len(newContent)) - 1}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1", SHA256: fromSHA256}}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{CurrentTarget: oldPath}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, modelsSubdir, "model-v2.bin")); !os.IsNotExist(err) {
+		t.Error("Sınırı aşan delta uygulanmamalıydı")
+	}
+	if mockS3.DownloadCalls != 2 {
+		t.Errorf("Delta reddedildikten sonra tam indirme yapılmalıydı (indirme sayısı: %d)", mockS3.DownloadCalls)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultDiskSafetyMargin, indirme sonrasında diskte boş kalması gereken
+// varsayılan alandır. Disk tamamen dolarsa durum dosyası ve günlük de yazılamaz.
+const defaultDiskSafetyMargin = 100 << 20 // 100 MiB
+
+var (
+	// ErrInsufficientSpace, modelin (güvenlik payıyla birlikte) diske sığmadığını belirtir.
+	// Geçici bir durumdur; yer açıldığında bir sonraki döngüde tekrar denenir.
+	ErrInsufficientSpace = errors.New("yetersiz disk alanı")
+	// ErrModelTooLarge, modelin yapılandırılan en büyük boyutu aştığını belirtir.
+	// Bu revizyon karantinaya alınır.
+	ErrModelTooLarge = errors.New("model izin verilen boyutu aşıyor")
+)
+
+// DiskConfig, indirmeden önce yapılan disk alanı ve boyut kontrollerini belirler.
+type DiskConfig struct {
+	MaxModelBytes     int64 `json:"max_model_bytes"`     // Kabul edilen en büyük model (paketlerde toplam) boyutu; 0 ise sınır yok
+	SafetyMarginBytes int64 `json:"safety_margin_bytes"` // İndirmeden sonra boş kalması gereken alan (varsayılan: 100 MiB)
+	GCOnLowSpace      bool  `json:"gc_on_low_space"`     // Yer yetmezse önce saklama politikasını (retention) uygula
+}
+
+// SafetyMargin, güvenlik payını (varsayılan değer uygulanmış olarak) döndürür.
+func (d DiskConfig) SafetyMargin() int64 {
+	if d.SafetyMarginBytes <= 0 {
+		return defaultDiskSafetyMargin
+	}
+	return d.SafetyMarginBytes
+}
+
+// DiskStatus, son disk alanı kontrolünün sonucudur (durum panelinde gösterilir).
+type DiskStatus struct {
+	FreeBytes    uint64 // Kontrol anında veri diskindeki boş alan
+	NeededBytes  int64  // İndirilecek modelin boyutu
+	Insufficient bool   // Model güvenlik payıyla birlikte diske sığmadıysa true
+}
+
+// ensureDiskSpace, 'size' baytlık bir modelin 'dir' dizininin bulunduğu diske
+// sığıp sığmadığını kontrol eder. Yer yetmezse ve gc_on_low_space açıksa önce
+// eski sürümler temizlenir. Boyut bilinmiyorsa (0) kontrol yapılmaz; boş alan
+// okunamıyorsa sadece uyarı verilir ve indirmeye devam edilir.
+// Çağıran, runMu kilidini tutmalıdır (temizlik için).
+func (p *Poller) ensureDiskSpace(size int64, dir string) error {
+	if size <= 0 {
+		return nil
+	}
+	if max := p.cfg.Disk.MaxModelBytes; max > 0 && size > max {
+		return fmt.Errorf("%w: %d bayt (sınır: %d bayt)", ErrModelTooLarge, size, max)
+	}
+
+	needed := uint64(size) + uint64(p.cfg.Disk.SafetyMargin())
+	free, err := freeDiskSpace(existingParent(dir))
+	if err != nil {
+		p.log.Printf("[Disk] UYARI: Boş disk alanı okunamadı, kontrol atlanıyor: %v", err)
+		return nil
+	}
+	if free < needed && p.cfg.Disk.GCOnLowSpace {
+		p.log.Printf("[Disk] Boş alan yetersiz (%d bayt boş, %d bayt gerekli). Eski sürümler temizleniyor...", free, needed)
+		if _, err := p.collectGarbage(); err != nil {
+			p.log.Printf("[GC] UYARI: Eski sürümler temizlenemedi: %v", err)
+		}
+		if free, err = freeDiskSpace(existingParent(dir)); err != nil {
+			return fmt.Errorf("boş disk alanı okunamadı: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.disk = DiskStatus{FreeBytes: free, NeededBytes: size, Insufficient: free < needed}
+	p.mu.Unlock()
+	if free < needed {
+		return fmt.Errorf("%w: model %d bayt, güvenlik payı %d bayt, boş alan %d bayt (%s)",
+			ErrInsufficientSpace, size, p.cfg.Disk.SafetyMargin(), free, dir)
+	}
+	return nil
+}
+
+// existingParent, 'dir' yolunun var olan en yakın üst dizinini döndürür.
+// Model dizini ilk indirmeden önce henüz oluşturulmamış olabilir.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
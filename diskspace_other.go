@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+package main
+
+import "errors"
+
+// freeDiskSpace, bu platformda desteklenmez; disk alanı kontrolü atlanır.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errors.New("boş disk alanı bu platformda okunamıyor")
+}
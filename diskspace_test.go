@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreeDiskSpace(t *testing.T) {
+	free, err := freeDiskSpace(existingParent(filepath.Join(t.TempDir(), "models", "yok")))
+	if err != nil {
+		t.Fatalf("freeDiskSpace() hata döndürdü: %v", err)
+	}
+	if free == 0 {
+		t.Error("Geçici dizinin bulunduğu diskte boş alan olmalıydı")
+	}
+}
+
+// TestPoller_ModelTooLarge, yapılandırılan sınırdan büyük bir modelin
+// indirilmeden reddedildiğini ve karantinaya alındığını test eder.
+func TestPoller_ModelTooLarge(t *testing.T) {
+	mockS3 := &MockS3Client{Listing: []ObjectVersion{{Key: "prod/model.bin", ETag: "v2", Size: 1000}}}
+	mockCfg := &Config{S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Disk: DiskConfig{MaxModelBytes: 100}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(t.TempDir(), "active_model_link"))
+
+	if err := p.RunOnce(); !errors.Is(err, ErrModelTooLarge) {
+		t.Fatalf("ErrModelTooLarge bekleniyordu, alınan: %v", err)
+	}
+	if mockS3.DownloadCalls != 0 {
+		t.Errorf("Büyük model indirilmemeliydi (indirme sayısı: %d)", mockS3.DownloadCalls)
+	}
+	if !p.isQuarantined("v2") {
+		t.Error("Sınırı aşan revizyon karantinaya alınmalıydı")
+	}
+}
+
+// TestPoller_InsufficientSpace, diske sığmayan bir modelin indirilmediğini,
+// durum paneline yazıldığını ve (geçici bir durum olduğu için) karantinaya
+// alınmadığını test eder.
+func TestPoller_InsufficientSpace(t *testing.T) {
+	mockS3 := &MockS3Client{Listing: []ObjectVersion{{Key: "prod/model.bin", ETag: "v2", Size: 1000}}}
+	mockCfg := &Config{S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Disk: DiskConfig{SafetyMarginBytes: 1 << 62}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(t.TempDir(), "active_model_link"))
+
+	if err := p.RunOnce(); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("ErrInsufficientSpace bekleniyordu, alınan: %v", err)
+	}
+	if mockS3.DownloadCalls != 0 {
+		t.Errorf("Yer yokken indirme yapılmamalıydı (indirme sayısı: %d)", mockS3.DownloadCalls)
+	}
+	if p.isQuarantined("v2") {
+		t.Error("Yetersiz disk alanı revizyonu karantinaya almamalıydı")
+	}
+	status := p.Status()
+	if !status.Disk.Insufficient || status.Disk.NeededBytes != 1000 || status.LastError == "" {
+		t.Errorf("Disk durumu panele yansımalıydı, alınan: %+v (hata: %q)", status.Disk, status.LastError)
+	}
+}
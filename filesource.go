@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SourceFile, modelin yerel dosya sisteminden (NFS/SMB bağlama noktası, USB
+// bellek) alındığı kaynaktır (Config.Source).
+const SourceFile = "file"
+
+// Dosya kaynağının revizyon kimliği türetme yöntemleri (FileSourceConfig.VersionBy).
+const (
+	fileVersionByMtime  = "mtime"  // Değiştirilme zamanı ve boyut (varsayılan, dosya okunmaz)
+	fileVersionBySHA256 = "sha256" // İçeriğin SHA-256 özeti
+)
+
+// Dosya kaynağı için varsayılan değerler.
+const (
+	defaultFileSettle   = 5 * time.Second  // Yazılmakta olan bir dosyanın durulması için beklenecek süre
+	fileWatchRetryDelay = 30 * time.Second // İzleme kurulamadığında (örn: bağlanmamış USB) tekrar deneme
+	mtimeETagPrefix     = "mt-"
+)
+
+// ErrFileNotSettled, dosyanın son 'settle_seconds' içinde değiştiğini, yani
+// muhtemelen hâlâ kopyalanmakta olduğunu belirtir. Bir sonraki kontrolde tekrar denenir.
+var ErrFileNotSettled = errors.New("dosya hâlâ yazılıyor")
+
+// errWatchUnsupported, bu platformda dosya izleme olmadığını belirtir;
+// değişiklikler sadece düzenli kontrolle (poll_interval_seconds) algılanır.
+var errWatchUnsupported = errors.New("dosya izleme bu platformda desteklenmiyor")
+
+// FileSourceConfig, "file" kaynağının izlediği dosyayı ve revizyonun nasıl
+// türetileceğini belirler.
+type FileSourceConfig struct {
+	Path          string `json:"path"`           // Model dosyası (örn: "/mnt/models/vision/latest.bin")
+	VersionBy     string `json:"version_by"`     // "mtime" (varsayılan) veya "sha256"
+	SettleSeconds int    `json:"settle_seconds"` // Son değişiklikten sonra beklenecek süre (varsayılan: 5)
+}
+
+// Settle, dosyanın kararlı sayılması için son değişiklikten sonra geçmesi
+// gereken süreyi döndürür.
+func (c FileSourceConfig) Settle() time.Duration {
+	if c.SettleSeconds <= 0 {
+		return defaultFileSettle
+	}
+	return time.Duration(c.SettleSeconds) * time.Second
+}
+
+// RealFileClient, S3Client arayüzünün modeli yerel dosya sisteminden alan
+// implementasyonudur. Anahtarlar (key) 'file.path' dosyasının dizinine göre
+// çözülür; boş anahtar dosyanın kendisidir. '<path>.sha256' ve '<path>.sig'
+// yan dosyaları S3'teki gibi çalışır.
+type RealFileClient struct {
+	path       string // 'file.path' (mutlak)
+	versionBy  string
+	settle     time.Duration
+	stagingDir string // Kopyaların tamamlanana kadar yazıldığı dizin
+
+	// Özet modunda her kontrolde dosyanın tamamını okumamak için son hesaplanan
+	// özetler, dosyanın değiştirilme zamanı ve boyutuyla birlikte saklanır.
+	mu     sync.Mutex
+	hashes map[string]fileHash
+}
+
+// fileHash, bir dosyanın hangi (değiştirilme zamanı, boyut) için hesaplanmış özetidir.
+type fileHash struct {
+	modTime time.Time
+	size    int64
+	sha256  string
+}
+
+// NewRealFileClient, 'cfg.File' ayarlarıyla yeni bir RealFileClient oluşturur.
+// Kopyalar önce 'stagingDir' altına yazılır.
+func NewRealFileClient(cfg *Config, stagingDir string) (*RealFileClient, error) {
+	if cfg.File.Path == "" {
+		return nil, fmt.Errorf("file kaynağı için 'file.path' gerekli")
+	}
+	if cfg.S3Prefix != "" || cfg.S3VersionID != "" {
+		return nil, fmt.Errorf("file kaynağında prefix izleme ve sürüm sabitleme desteklenmiyor")
+	}
+	versionBy := cfg.File.VersionBy
+	switch versionBy {
+	case "":
+		versionBy = fileVersionByMtime
+	case fileVersionByMtime, fileVersionBySHA256:
+	default:
+		return nil, fmt.Errorf("geçersiz file.version_by: '%s' (\"mtime\" veya \"sha256\")", versionBy)
+	}
+	abs, err := filepath.Abs(cfg.File.Path)
+	if err != nil {
+		return nil, fmt.Errorf("geçersiz file.path '%s': %w", cfg.File.Path, err)
+	}
+	return &RealFileClient{
+		path:       abs,
+		versionBy:  versionBy,
+		settle:     cfg.File.Settle(),
+		stagingDir: stagingDir,
+		hashes:     make(map[string]fileHash),
+	}, nil
+}
+
+// resolve, bir anahtarı dosya yoluna çevirir.
+func (f *RealFileClient) resolve(key string) string {
+	if key == "" {
+		return f.path
+	}
+	name := filepath.FromSlash(key)
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(filepath.Dir(f.path), name)
+}
+
+// HeadObject, dosyanın güncel revizyonunu okur. Dosya son 'settle_seconds'
+// içinde değiştiyse (hâlâ kopyalanıyor olabilir) ErrFileNotSettled döner.
+// Dosya kaynaklarında sürüm sabitleme yoktur; 'bucket' ve 'versionID' kullanılmaz.
+func (f *RealFileClient) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	name := f.resolve(key)
+	info, err := os.Stat(name)
+	if err != nil {
+		return ObjectVersion{}, fileError(name, err)
+	}
+	if info.IsDir() {
+		return ObjectVersion{}, fmt.Errorf("'%s' bir dizin, dosya bekleniyordu", name)
+	}
+	if age := time.Since(info.ModTime()); age < f.settle {
+		return ObjectVersion{}, fmt.Errorf("'%s' %v önce değişti: %w", name, age.Round(time.Second), ErrFileNotSettled)
+	}
+	etag, err := f.version(name, info)
+	if err != nil {
+		return ObjectVersion{}, err
+	}
+	return ObjectVersion{
+		Key:          filepath.ToSlash(name),
+		ETag:         etag,
+		LastModified: info.ModTime(),
+		Size:         info.Size(),
+	}, nil
+}
+
+// version, dosyanın revizyon kimliğini 'version_by' ayarına göre türetir.
+func (f *RealFileClient) version(name string, info os.FileInfo) (string, error) {
+	if f.versionBy != fileVersionBySHA256 {
+		return fmt.Sprintf("%s%d-%d", mtimeETagPrefix, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	f.mu.Lock()
+	cached, ok := f.hashes[name]
+	f.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.sha256, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return "", fileError(name, err)
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("dosya okunamadı (%s): %w", name, err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	f.mu.Lock()
+	f.hashes[name] = fileHash{modTime: info.ModTime(), size: info.Size(), sha256: digest}
+	f.mu.Unlock()
+	return digest, nil
+}
+
+// ExpectedSHA256, revizyon için yayınlanmış SHA-256 özetini '<path>.sha256'
+// yan dosyasından okur.
+func (f *RealFileClient) ExpectedSHA256(bucket string, obj ObjectVersion) (string, error) {
+	return lookupSHA256(obj, func(key string) ([]byte, error) {
+		return f.FetchObject(bucket, key)
+	})
+}
+
+// ListObjects, dosya kaynaklarında desteklenmez.
+func (f *RealFileClient) ListObjects(bucket, prefix string) ([]ObjectVersion, error) {
+	return nil, fmt.Errorf("file kaynağında listeleme (prefix izleme) desteklenmiyor")
+}
+
+// FetchObject, küçük bir dosyanın (imza, özet, manifesto) içeriğini belleğe okur.
+func (f *RealFileClient) FetchObject(bucket, key string) ([]byte, error) {
+	name := f.resolve(key)
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fileError(name, err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxFetchSize))
+	if err != nil {
+		return nil, fmt.Errorf("dosya okunamadı (%s): %w", name, err)
+	}
+	return content, nil
+}
+
+// DownloadObject, dosyayı S3 indirmeleriyle aynı şekilde önce staging'e
+// kopyalar, özetini kopyalama sırasında hesaplar ve tamamlandığında hedefe
+// taşır. Kopyalama yarıda kalırsa (örn: bağlantısı kopan bir ağ paylaşımı) bir
+// sonraki denemede, dosya değişmediyse kaldığı yerden devam edilir. Özet
+// modunda kopyanın revizyondaki özetle aynı olduğu ayrıca doğrulanır.
+func (f *RealFileClient) DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error) {
+	if expectedSHA256 == "" && f.versionBy == fileVersionBySHA256 && obj.ETag != "" {
+		expectedSHA256 = obj.ETag
+	}
+	return stageResumable(f.stagingDir, destinationPath, expectedSHA256, f.rangeFetcher(obj.Key, obj.ETag))
+}
+
+// rangeFetcher, stageResumable için 'key' dosyasını 'offset'ten itibaren açan
+// fonksiyonu döndürür. Dosyanın güncel revizyonu 'pinETag' (veya yarım kopyanın
+// revizyonu) ile aynı değilse errStalePartial döner.
+func (f *RealFileClient) rangeFetcher(key, pinETag string) rangeFetcher {
+	return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		if ifMatch == "" {
+			ifMatch = pinETag
+		}
+		name := f.resolve(key)
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, "", 0, fileError(name, err)
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, "", 0, fmt.Errorf("dosya bilgisi okunamadı (%s): %w", name, err)
+		}
+		etag, err := f.version(name, info)
+		if err != nil {
+			file.Close()
+			return nil, "", 0, err
+		}
+		if ifMatch != "" && etag != ifMatch {
+			file.Close()
+			return nil, "", 0, fmt.Errorf("'%s' kontrol edildikten sonra değişti: %w", name, errStalePartial)
+		}
+		if offset > 0 {
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				file.Close()
+				return nil, "", 0, fmt.Errorf("dosyada konumlanılamadı (%s): %w", name, err)
+			}
+		}
+		return file, etag, info.Size(), nil
+	}
+}
+
+// fileError, bir dosya hatasını çevirir; olmayan dosyalar ErrObjectNotFound döner.
+func fileError(name string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("'%s': %w", name, ErrObjectNotFound)
+	}
+	return err
+}
+
+// runFileWatch, "file" kaynağının dizinini 'ctx' iptal edilene kadar izler ve
+// model dosyası (veya yan dosyaları) değiştiğinde, dosyanın durulması için
+// 'settle_seconds' bekledikten sonra hedefin hemen kontrol edilmesini ister.
+// İzleme desteklenmiyorsa veya kurulamıyorsa (örn: USB bellek takılı değil)
+// düzenli kontrol yedek olarak çalışmaya devam eder.
+func runFileWatch(ctx context.Context, p *Poller) {
+	target, err := filepath.Abs(p.cfg.File.Path)
+	if err != nil {
+		p.log.Printf("[Watch] UYARI: Dosya izlenemiyor: %v", err)
+		return
+	}
+	if p.cfg.S3Key != "" {
+		target = filepath.Join(filepath.Dir(target), filepath.FromSlash(p.cfg.S3Key))
+	}
+	dir, base := filepath.Dir(target), filepath.Base(target)
+	settle := p.cfg.File.Settle()
+
+	var mu sync.Mutex
+	var timer *time.Timer
+	onChange := func(name string) {
+		if !strings.HasPrefix(name, base) {
+			return
+		}
+		// Kopyalama sürerken gelen olaylar zamanlayıcıyı yeniler; kontrol son
+		// değişiklikten 'settle' süre sonra yapılır.
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(settle+time.Second, func() {
+			p.log.Printf("[Watch] '%s' değişti. Model kontrol edilecek.", filepath.Join(dir, name))
+			p.Trigger()
+		})
+	}
+
+	for ctx.Err() == nil {
+		err := watchDir(ctx, dir, onChange)
+		if errors.Is(err, errWatchUnsupported) {
+			log.Printf("[Watch] %v; '%s' sadece düzenli kontrolle izlenecek.", err, dir)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		p.log.Printf("[Watch] UYARI: '%s' izlenemiyor, %v sonra tekrar denenecek: %v", dir, fileWatchRetryDelay, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(fileWatchRetryDelay):
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSourceFile, 'name' dosyasını yazar ve değiştirilme zamanını 'settle'
+// süresinin dışına (geçmişe) çeker.
+func writeSourceFile(t *testing.T, name string, content []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRealFileClient_HeadAndDownload(t *testing.T) {
+	share := t.TempDir()
+	content := []byte("model ağırlıkları")
+	modTime := time.Now().Add(-time.Hour)
+	writeSourceFile(t, filepath.Join(share, "latest.bin"), content, modTime)
+	writeSourceFile(t, filepath.Join(share, "latest.bin.sha256"), []byte(sha256Hex(content)+"  latest.bin\n"), modTime)
+
+	client, err := NewRealFileClient(&Config{File: FileSourceConfig{Path: filepath.Join(share, "latest.bin")}}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealFileClient() hata döndürdü: %v", err)
+	}
+	obj, err := client.HeadObject("", "", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	if obj.ETag == "" || obj.Size != int64(len(content)) || !obj.LastModified.Equal(modTime) {
+		t.Errorf("Beklenmeyen revizyon bilgisi: %+v", obj)
+	}
+
+	expected, err := client.ExpectedSHA256("", obj)
+	if err != nil || expected != sha256Hex(content) {
+		t.Fatalf("Özet yan dosyadan okunmalıydı, alınan: %q, %v", expected, err)
+	}
+	dest := filepath.Join(t.TempDir(), "model.bin")
+	if _, err := client.DownloadObject("", obj, dest, expected); err != nil {
+		t.Fatalf("DownloadObject() hata döndürdü: %v", err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != string(content) {
+		t.Errorf("Model staging üzerinden hedefe kopyalanmalıydı, alınan: %q", got)
+	}
+
+	if _, err := client.HeadObject("", "missing.bin", ""); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("ErrObjectNotFound bekleniyordu, alınan: %v", err)
+	}
+}
+
+// TestRealFileClient_NotSettled, hâlâ yazılmakta olabilecek (yeni değişmiş)
+// bir dosyanın revizyon olarak kabul edilmediğini test eder.
+func TestRealFileClient_NotSettled(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "latest.bin")
+	writeSourceFile(t, name, []byte("yarım"), time.Now())
+
+	client, _ := NewRealFileClient(&Config{File: FileSourceConfig{Path: name}}, t.TempDir())
+	if _, err := client.HeadObject("", "", ""); !errors.Is(err, ErrFileNotSettled) {
+		t.Errorf("ErrFileNotSettled bekleniyordu, alınan: %v", err)
+	}
+}
+
+// TestRealFileClient_SHA256Version, özet modunda revizyonun içerikten
+// türetildiğini ve kontrol ile kopyalama arasında değişen bir dosyanın
+// kopyalanmadığını test eder.
+func TestRealFileClient_SHA256Version(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "latest.bin")
+	writeSourceFile(t, name, []byte("v1"), time.Now().Add(-time.Hour))
+
+	client, _ := NewRealFileClient(&Config{File: FileSourceConfig{Path: name, VersionBy: "sha256"}}, t.TempDir())
+	obj, err := client.HeadObject("", "", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	if obj.ETag != sha256Hex([]byte("v1")) {
+		t.Errorf("ETag içeriğin özeti olmalıydı, alınan: %s", obj.ETag)
+	}
+
+	writeSourceFile(t, name, []byte("v2"), time.Now().Add(-time.Minute))
+	dest := filepath.Join(t.TempDir(), "model.bin")
+	if _, err := client.DownloadObject("", obj, dest, ""); !errors.Is(err, errStalePartial) {
+		t.Errorf("Değişen dosya errStalePartial döndürmeliydi, alınan: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Değişen dosya hedefe yazılmamalıydı")
+	}
+}
+
+// TestPoller_FileSource, "file" kaynağıyla uçtan uca bir döngüyü test eder:
+// paylaşımdaki yeni dosya staging'e kopyalanır, test edilir ve aktif edilir.
+func TestPoller_FileSource(t *testing.T) {
+	dir, share := t.TempDir(), t.TempDir()
+	name := filepath.Join(share, "latest.bin")
+	writeSourceFile(t, name, []byte("ilk model"), time.Now().Add(-time.Hour))
+
+	cfg := &Config{DataDir: dir, Source: SourceFile, File: FileSourceConfig{Path: name}, DeployScriptPath: "deploy.sh"}
+	client, err := NewRealFileClient(cfg, filepath.Join(dir, stagingSubdir))
+	if err != nil {
+		t.Fatalf("NewRealFileClient() hata döndürdü: %v", err)
+	}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{}
+	p := NewPoller(cfg, client, mockDeploy, mockLink, &MockStateStore{}, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	writeSourceFile(t, name, []byte("ikinci model"), time.Now().Add(-time.Minute))
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if len(mockDeploy.Calls) != 2 {
+		t.Fatalf("Yeni model deploy edilmeliydi: %v", mockDeploy.Calls)
+	}
+	got, _ := os.ReadFile(mockLink.CurrentTarget)
+	if string(got) != "ikinci model" {
+		t.Errorf("Aktif bağ kopyalanan yeni modeli göstermeliydi, alınan: %q", got)
+	}
+}
+
+// TestWatchDir, dizindeki bir değişikliğin dosya adıyla bildirildiğini test eder.
+func TestWatchDir(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	names := make(chan string, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- watchDir(ctx, dir, func(name string) {
+			select {
+			case names <- name:
+			default:
+			}
+		})
+	}()
+
+	// İzlemenin kurulmasını beklemek yerine olay gelene kadar dosya yeniden yazılır.
+	deadline := time.After(5 * time.Second)
+	for {
+		os.WriteFile(filepath.Join(dir, "latest.bin"), []byte("model"), 0o644)
+		select {
+		case err := <-errc:
+			if errors.Is(err, errWatchUnsupported) {
+				t.Skip(err)
+			}
+			t.Fatalf("watchDir() beklenmedik şekilde sona erdi: %v", err)
+		case name := <-names:
+			if name != "latest.bin" {
+				t.Errorf("Beklenen 'latest.bin', alınan: '%s'", name)
+			}
+			cancel()
+			if err := <-errc; !errors.Is(err, context.Canceled) {
+				t.Errorf("İptal sonrası context.Canceled bekleniyordu, alınan: %v", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Değişiklik bildirilmedi")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
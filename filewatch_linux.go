@@ -0,0 +1,64 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask, model dosyasının yazılıp kapatıldığını, taşındığını veya
+// silindiğini bildiren olaylardır. Dizin kaldırılır veya dosya sistemi
+// ayrılırsa (IN_IGNORED) izleme sona erer.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watchDir, 'dir' dizinini inotify ile izler ve değişen her dosyanın adıyla
+// 'onChange' fonksiyonunu çağırır. 'ctx' iptal edilene veya izleme sona erene
+// kadar döner.
+func watchDir(ctx context.Context, dir string, onChange func(name string)) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("inotify başlatılamadı: %w", err)
+	}
+	// Engellemeyen tanımlayıcı Go'nun çalışma zamanı poller'ına verilir; böylece
+	// Close, bekleyen Read çağrısını sonlandırır.
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	if _, err := syscall.InotifyAddWatch(fd, dir, inotifyMask); err != nil {
+		return fmt.Errorf("inotify izlemesi eklenemedi (%s): %w", dir, err)
+	}
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("inotify olayları okunamadı: %w", err)
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&(syscall.IN_IGNORED|syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF|syscall.IN_UNMOUNT) != 0 {
+				return fmt.Errorf("'%s' dizini kaldırıldı veya ayrıldı", dir)
+			}
+			// Ad, NUL baytlarıyla hizalanmıştır.
+			name := string(nameBytes)
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			if name != "" {
+				onChange(name)
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "context"
+
+// watchDir, inotify olmayan platformlarda errWatchUnsupported döndürür;
+// değişiklikler sadece düzenli kontrolle algılanır.
+func watchDir(ctx context.Context, dir string, onChange func(name string)) error {
+	return errWatchUnsupported
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrGCBusy, bir kontrol döngüsü (indirme veya dağıtım) sürerken isteğe bağlı
+// temizlik istendiğinde döner.
+var ErrGCBusy = errors.New("bir dağıtım sürüyor, temizlik daha sonra tekrar denenmeli")
+
+// RetentionConfig, 'models/' altında eski model sürümlerinin ne kadar süre
+// tutulacağını belirler. Tüm alanlar boşsa (varsayılan) hiçbir sürüm silinmez.
+// Aktif model ve rollback adayı (bir önceki stabil model) her zaman tutulur.
+type RetentionConfig struct {
+	KeepVersions  int   `json:"keep_versions"`   // Tutulacak en fazla sürüm sayısı (aktif ve rollback adayı dahil)
+	MaxBytes      int64 `json:"max_bytes"`       // Tüm sürümlerin toplam boyut sınırı (bayt)
+	MinAgeSeconds int   `json:"min_age_seconds"` // Bu süreden yeni sürümler sınırlar aşılsa da silinmez
+}
+
+// Enabled, en az bir sınırın ayarlanıp ayarlanmadığını döndürür.
+func (r RetentionConfig) Enabled() bool {
+	return r.KeepVersions > 0 || r.MaxBytes > 0
+}
+
+// GCResult, bir temizlik çalışmasının sonucudur.
+type GCResult struct {
+	Removed    []string `json:"removed"`     // Silinen model dosyaları veya dizinleri
+	FreedBytes int64    `json:"freed_bytes"` // Boşaltılan yaklaşık alan
+	KeptBytes  int64    `json:"kept_bytes"`  // Kalan sürümlerin toplam boyutu
+}
+
+// modelVersion, 'models/' altındaki tek bir sürümdür (dosya veya dizin).
+type modelVersion struct {
+	path      string
+	size      int64
+	addedAt   time.Time // Sürümün kaydedildiği zaman (bkz. versionTimes)
+	protected bool
+}
+
+// CollectGarbage, saklama politikasını isteğe bağlı (örn: HTTP üzerinden)
+// uygular. Bir kontrol döngüsü sürüyorsa beklemez, ErrGCBusy döndürür.
+func (p *Poller) CollectGarbage() (GCResult, error) {
+	if !p.runMu.TryLock() {
+		return GCResult{}, ErrGCBusy
+	}
+	defer p.runMu.Unlock()
+	return p.collectGarbage()
+}
+
+// collectGarbage, saklama politikasına göre eski model sürümlerini siler.
+// Çağıran, runMu kilidini tutmalıdır (RunOnce içinden veya CollectGarbage ile).
+//
+// Sürümler yeniden eskiye sıralanır; aktif model, rollback adayı ve izlenen
+// (soak) dağıtım korunur ve sayıya dahil edilir. Kalan sürümlerden önce
+// KeepVersions'ı aşanlar, sonra toplam boyut MaxBytes'ın altına inene kadar
+// en eskiler silinir. MinAgeSeconds'tan yeni sürümlere dokunulmaz.
+func (p *Poller) collectGarbage() (GCResult, error) {
+	var result GCResult
+	policy := p.cfg.Retention
+	if !policy.Enabled() {
+		return result, nil
+	}
+
+	versions, err := p.listModelVersions()
+	if err != nil {
+		return result, err
+	}
+
+	minAge := time.Duration(policy.MinAgeSeconds) * time.Second
+	kept := 0
+	for _, v := range versions {
+		if v.protected {
+			kept++
+		}
+	}
+
+	var survivors, removable []*modelVersion
+	for _, v := range versions {
+		oldEnough := time.Since(v.addedAt) >= minAge
+		switch {
+		case v.protected:
+			survivors = append(survivors, v)
+		case policy.KeepVersions > 0 && kept >= policy.KeepVersions && oldEnough:
+			removable = append(removable, v)
+		default:
+			survivors = append(survivors, v)
+			kept++
+		}
+	}
+
+	// Boyut sınırı: kalan sürümlerden en eskiler, toplam sınırın altına inene kadar silinir.
+	for _, v := range survivors {
+		result.KeptBytes += v.size
+	}
+	if policy.MaxBytes > 0 {
+		for i := len(survivors) - 1; i >= 0 && result.KeptBytes > policy.MaxBytes; i-- {
+			v := survivors[i]
+			if v.protected || time.Since(v.addedAt) < minAge {
+				continue
+			}
+			removable = append(removable, v)
+			result.KeptBytes -= v.size
+		}
+		if result.KeptBytes > policy.MaxBytes {
+			p.log.Printf("[GC] UYARI: Korunan sürümler boyut sınırını aşıyor (%d > %d bayt).", result.KeptBytes, policy.MaxBytes)
+		}
+	}
+
+	for _, v := range removable {
+		if err := os.RemoveAll(v.path); err != nil {
+			p.log.Printf("[GC] UYARI: Eski sürüm silinemedi (%s): %v", v.path, err)
+			result.KeptBytes += v.size
+			continue
+		}
+		p.log.Printf("[GC] Eski sürüm silindi: %s (%d bayt)", v.path, v.size)
+		result.Removed = append(result.Removed, v.path)
+		result.FreedBytes += v.size
+	}
+	if len(result.Removed) > 0 {
+		syncDir(p.modelsDir())
+	}
+	p.pruneStore()
+	return result, nil
+}
+
+// modelsDir, bu hedefin modellerinin indirildiği dizindir ('<data_dir>/models/<hedef>').
+// Yol mutlaktır: sembolik bağın hedefi, çalışma dizinine değil bağın bulunduğu
+// dizine göre çözüldüğü için göreli bir 'data_dir' ile yazılan bağ kırık olurdu.
+func (p *Poller) modelsDir() string {
+	dir := filepath.Join(p.cfg.DataRoot(), modelsSubdir, p.cfg.Name)
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// resolveLinkTarget, aktif bağdan okunan hedefi işletim sisteminin çözdüğü gibi
+// çözer: göreli hedefler (eski sürümlerin veya elle oluşturulmuş bağlar) bağın
+// bulunduğu dizine göredir.
+func (p *Poller) resolveLinkTarget(target string) string {
+	if target == "" || filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(p.activeModelPath), target)
+}
+
+// listModelVersions, 'models/<hedef>/' altındaki sürümleri (yeniden eskiye)
+// döndürür. Sadece ajanın oluşturduğu 'model-*' ve 'bundle-*' girdileri
+// sayılır; yarım kalmış geçici dosyalar ve diğer hedeflerin dizinleri atlanır.
+func (p *Poller) listModelVersions() ([]*modelVersion, error) {
+	dir := p.modelsDir()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	protected := p.protectedPaths()
+	recorded := p.versionTimes()
+	var versions []*modelVersion
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "model-") && !strings.HasPrefix(name, "bundle-") {
+			continue
+		}
+		if strings.HasSuffix(name, tmpDirSuffix) || strings.HasSuffix(name, deltaSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Bu arada silinmiş olabilir.
+		}
+		path := filepath.Join(dir, name)
+		addedAt, ok := recorded[path]
+		if !ok {
+			// Kaydı olmayan sürümler (içerik deposu kapalı) için dosyanın kendi zamanı kullanılır.
+			addedAt = info.ModTime()
+		}
+		versions = append(versions, &modelVersion{
+			path:      path,
+			size:      diskUsage(path),
+			addedAt:   addedAt,
+			protected: isProtected(protected, path),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].addedAt.After(versions[j].addedAt) })
+	return versions, nil
+}
+
+// versionTimes, sürümlerin durum dosyasında ve içerik deposu kayıtlarında
+// tutulan zamanlarını (mutlak yol -> zaman) döndürür. İçerik deposundaki
+// sürümler aynı blob'a sabit bağlantı olduğundan değişiklik zamanlarını
+// paylaşır; sıralama için dosya sistemi zamanı kullanılamaz.
+func (p *Poller) versionTimes() map[string]time.Time {
+	times := make(map[string]time.Time)
+	add := func(path string, t time.Time) {
+		if path == "" || t.IsZero() {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil && t.After(times[abs]) {
+			times[abs] = t
+		}
+	}
+	if p.cas != nil {
+		refs, err := p.cas.Refs(p.cfg.Name)
+		if err != nil {
+			p.log.Printf("[GC] UYARI: Depo kayıtları okunamadı, dosya zamanları kullanılıyor: %v", err)
+		}
+		for _, ref := range refs {
+			add(ref.ModelPath, ref.CreatedAt)
+		}
+	}
+	p.mu.RLock()
+	add(p.deployedModel, p.deployedAt)
+	p.mu.RUnlock()
+	return times
+}
+
+// protectedPaths, hiçbir koşulda silinmemesi gereken model yollarıdır: aktif
+// bağın hedefi, stabil model, rollback adayı ve izleme penceresindeki dağıtım.
+func (p *Poller) protectedPaths() map[string]bool {
+	paths := make(map[string]bool)
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			paths[abs] = true
+		}
+	}
+	if target, err := p.linker.Get(p.activeModelPath); err == nil && target != "" {
+		add(p.resolveLinkTarget(target))
+	}
+	p.mu.RLock()
+	add(p.deployedModel)
+	add(p.previousModel)
+	if p.soak != nil {
+		add(p.soak.ModelPath)
+		add(p.soak.PreviousTarget)
+	}
+	p.mu.RUnlock()
+	return paths
+}
+
+// isProtected, 'path' yolunun korunan yollardan biri olup olmadığını döndürür.
+func isProtected(protected map[string]bool, path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return true // Emin olamıyorsak silmeyiz.
+	}
+	return protected[abs]
+}
+
+// diskUsage, bir dosyanın veya dizinin (içindeki tüm dosyalarla) boyutunu döndürür.
+func diskUsage(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// pruneStore, içerik deposu açıksa model dizini silinmiş sürümlerin kayıtlarını
+// ve hiçbir kayıtta kullanılmayan blob'ları siler. 'models/' altındaki dosyalar
+// blob'lara sabit bağlantı olduğundan bir blob'u silmek çalışan modeli etkilemez.
+func (p *Poller) pruneStore() {
+	if p.cas == nil {
+		return
+	}
+	refs, err := p.cas.Refs(p.cfg.Name)
+	if err != nil {
+		p.log.Printf("[GC] UYARI: Depo kayıtları okunamadı: %v", err)
+		return
+	}
+	for _, ref := range refs {
+		if _, err := os.Stat(ref.ModelPath); os.IsNotExist(err) {
+			os.Remove(p.cas.refPath(p.cfg.Name, ref.Version))
+		}
+	}
+	removed, err := p.cas.Prune()
+	if err != nil {
+		p.log.Printf("[GC] UYARI: Depo temizlenemedi: %v", err)
+		return
+	}
+	if removed > 0 {
+		p.log.Printf("[GC] Depoda kullanılmayan %d blob silindi.", removed)
+	}
+}
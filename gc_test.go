@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeModelVersions, 'models/' altında model-v1.bin ... model-vN.bin
+// dosyalarını (v1 en eski olacak şekilde) oluşturur ve yollarını döndürür.
+func writeModelVersions(t *testing.T, dir string, n, size int) []string {
+	t.Helper()
+	modelsDir := filepath.Join(dir, modelsSubdir)
+	os.MkdirAll(modelsDir, 0o755)
+	var paths []string
+	for i := 1; i <= n; i++ {
+		path := filepath.Join(modelsDir, fmt.Sprintf("model-v%d.bin", i))
+		os.WriteFile(path, make([]byte, size), 0o644)
+		modTime := time.Now().Add(-time.Duration(n-i+1) * time.Hour)
+		os.Chtimes(path, modTime, modTime)
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// TestPoller_GCKeepVersions, en fazla N sürümün tutulduğunu ve aktif model ile
+// rollback adayının (eski olsalar bile) hiçbir zaman silinmediğini test eder.
+func TestPoller_GCKeepVersions(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeModelVersions(t, dir, 5, 10)
+
+	// Aktif model v2, rollback adayı v1; v5 ise testte başarısız olmuş yeni bir sürüm.
+	mockCfg := &Config{DataDir: dir, DeployScriptPath: "deploy.sh", Retention: RetentionConfig{KeepVersions: 3}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v2", ActiveModelPath: paths[1], PreviousModel: paths[0]}}
+	p := NewPoller(mockCfg, &MockS3Client{}, &MockDeployer{}, &MockLinker{CurrentTarget: paths[1]}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	result, err := p.CollectGarbage()
+	if err != nil {
+		t.Fatalf("CollectGarbage() hata döndürdü: %v", err)
+	}
+
+	want := map[string]bool{paths[0]: true, paths[1]: true, paths[2]: false, paths[3]: false, paths[4]: true}
+	for path, keep := range want {
+		if exists(path) != keep {
+			t.Errorf("%s: tutulmalı=%v, diskte=%v", filepath.Base(path), keep, exists(path))
+		}
+	}
+	if len(result.Removed) != 2 || result.FreedBytes != 20 || result.KeptBytes != 30 {
+		t.Errorf("Beklenmedik GC sonucu: %+v", result)
+	}
+}
+
+// TestPoller_GCMaxBytes, toplam boyut sınırı aşıldığında en eski sürümlerin
+// silindiğini ve yeni sürümlere MinAgeSeconds dolmadan dokunulmadığını test eder.
+func TestPoller_GCMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeModelVersions(t, dir, 4, 100)
+	// v4 az önce indirilmiş.
+	os.Chtimes(paths[3], time.Now(), time.Now())
+
+	mockCfg := &Config{DataDir: dir, DeployScriptPath: "deploy.sh", Retention: RetentionConfig{MaxBytes: 150, MinAgeSeconds: 600}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v3", ActiveModelPath: paths[2]}}
+	p := NewPoller(mockCfg, &MockS3Client{}, &MockDeployer{}, &MockLinker{CurrentTarget: paths[2]}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	if _, err := p.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage() hata döndürdü: %v", err)
+	}
+	if exists(paths[0]) || exists(paths[1]) {
+		t.Error("Boyut sınırı aşıldığı için en eski sürümler silinmeliydi")
+	}
+	if !exists(paths[2]) || !exists(paths[3]) {
+		t.Error("Aktif model ve yeni indirilen sürüm silinmemeliydi")
+	}
+}
+
+// TestPoller_GCAfterDeploy, başarılı bir dağıtımdan sonra saklama politikasının
+// uygulandığını ve bir önceki modelin rollback adayı olarak tutulduğunu test eder.
+func TestPoller_GCAfterDeploy(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeModelVersions(t, dir, 3, 10)
+	newModel := filepath.Join(dir, modelsSubdir, "model-v4.bin")
+
+	mockS3 := &MockS3Client{EtagToReturn: "v4", Objects: map[string][]byte{"prod/model.bin": []byte("v4")}}
+	mockCfg := &Config{DataDir: dir, S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh", Retention: RetentionConfig{KeepVersions: 2}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v3", ActiveModelPath: paths[2], PreviousModel: paths[1]}}
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{CurrentTarget: paths[2]}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if !exists(newModel) || !exists(paths[2]) {
+		t.Error("Yeni model ve rollback adayı (v3) tutulmalıydı")
+	}
+	if exists(paths[0]) || exists(paths[1]) {
+		t.Error("Dağıtımdan sonra eski sürümler silinmeliydi")
+	}
+	if mockStore.State.PreviousModel != paths[2] {
+		t.Errorf("Rollback adayı kaydedilmeliydi, alınan: %s", mockStore.State.PreviousModel)
+	}
+}
+
+// TestPoller_GCRelativeLink, aktif bağın hedefi (bağın dizinine göre) göreli
+// ve 'data_dir' göreli olduğunda bağın gösterdiği modelin silinmediğini test eder.
+func TestPoller_GCRelativeLink(t *testing.T) {
+	t.Chdir(t.TempDir())
+	paths := writeModelVersions(t, "data", 3, 10)
+
+	mockCfg := &Config{DataDir: "data", DeployScriptPath: "deploy.sh", Retention: RetentionConfig{KeepVersions: 1}}
+	link := mockCfg.ActiveLinkPath()
+	linker := &RealLinker{}
+	if err := linker.Set(filepath.Join(modelsSubdir, "model-v1.bin"), link); err != nil {
+		t.Fatalf("Bağ oluşturulamadı: %v", err)
+	}
+	p := NewPoller(mockCfg, &MockS3Client{}, &MockDeployer{}, linker, &MockStateStore{State: &AgentState{ETag: "v1"}}, &MockHealthProber{}, link)
+
+	if _, err := p.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage() hata döndürdü: %v", err)
+	}
+	if _, err := os.Stat(link); err != nil {
+		t.Errorf("Aktif bağın gösterdiği model silinmemeliydi: %v", err)
+	}
+	if exists(paths[1]) || exists(paths[2]) {
+		t.Error("Bağlı olmayan sürümler silinmeliydi")
+	}
+}
+
+// TestPoller_GCOrderWithContentStore, içerik deposunda aynı blob'a sabit
+// bağlantı olan (dosya zamanı ortak) sürümlerin kayıt zamanına göre
+// sıralandığını ve en eskinin silindiğini test eder.
+func TestPoller_GCOrderWithContentStore(t *testing.T) {
+	dir := t.TempDir()
+	mockCfg := &Config{DataDir: dir, DeployScriptPath: "deploy.sh", ContentStore: true, Retention: RetentionConfig{KeepVersions: 2}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v3"}}
+	p := NewPoller(mockCfg, &MockS3Client{}, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	os.MkdirAll(p.modelsDir(), 0o755)
+	var paths []string
+	for i := 1; i <= 3; i++ {
+		path := filepath.Join(p.modelsDir(), fmt.Sprintf("model-v%d.bin", i))
+		if i == 1 {
+			os.WriteFile(path, []byte("aynı içerik"), 0o644)
+		} else if err := os.Link(paths[0], path); err != nil {
+			t.Skipf("Sabit bağlantı desteklenmiyor: %v", err)
+		}
+		ref := VersionRef{Version: fmt.Sprintf("v%d", i), ModelPath: path, CreatedAt: time.Now().Add(-time.Duration(4-i) * time.Hour)}
+		if err := p.cas.WriteRef(mockCfg.Name, ref); err != nil {
+			t.Fatalf("WriteRef() hata döndürdü: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if _, err := p.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage() hata döndürdü: %v", err)
+	}
+	want := map[string]bool{paths[0]: false, paths[1]: true, paths[2]: true}
+	for path, keep := range want {
+		if exists(path) != keep {
+			t.Errorf("%s: tutulmalı=%v, diskte=%v", filepath.Base(path), keep, exists(path))
+		}
+	}
+}
+
+func TestPoller_GCBusy(t *testing.T) {
+	p := NewPoller(&Config{Retention: RetentionConfig{KeepVersions: 1}}, &MockS3Client{}, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, filepath.Join(t.TempDir(), "active_model_link"))
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if _, err := p.CollectGarbage(); !errors.Is(err, ErrGCBusy) {
+		t.Errorf("Döngü sürerken ErrGCBusy bekleniyordu, alınan: %v", err)
+	}
+}
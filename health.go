@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Sağlık kontrolleri için varsayılan değerler.
+const (
+	defaultHealthRetries  = 3
+	defaultHealthInterval = 5 * time.Second
+	defaultHealthDeadline = 60 * time.Second
+	defaultCheckTimeout   = 5 * time.Second
+)
+
+// RealHealthProber, HealthProber arayüzünün gerçek HTTP, TCP ve komut
+// kontrolleri yapan implementasyonudur.
+type RealHealthProber struct{}
+
+// Probe, tek bir sağlık kontrolünü çalıştırır. Kontrol, 'ctx' iptal
+// edildiğinde veya kontrolün kendi süre sınırı dolduğunda başarısız olur.
+func (rp *RealHealthProber) Probe(ctx context.Context, check HealthCheck) error {
+	timeout := defaultCheckTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch check.Type {
+	case "http":
+		return probeHTTP(ctx, check)
+	case "tcp":
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", check.Address)
+		if err != nil {
+			return fmt.Errorf("TCP bağlantısı kurulamadı (%s): %w", check.Address, err)
+		}
+		return conn.Close()
+	case "exec":
+		if len(check.Command) == 0 {
+			return fmt.Errorf("exec kontrolü için komut belirtilmemiş")
+		}
+		output, err := exec.CommandContext(ctx, check.Command[0], check.Command[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("komut '%s' hatayla sonlandı: %w. Çıktı: %s", strings.Join(check.Command, " "), err, string(output))
+		}
+		return nil
+	default:
+		return fmt.Errorf("bilinmeyen sağlık kontrolü tipi: '%s'", check.Type)
+	}
+}
+
+// probeHTTP, bir HTTP GET isteği yapar ve durum kodunu ve (istenirse) gövdeyi doğrular.
+func probeHTTP(ctx context.Context, check HealthCheck) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return fmt.Errorf("HTTP isteği oluşturulamadı (%s): %w", check.URL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP isteği başarısız (%s): %w", check.URL, err)
+	}
+	defer resp.Body.Close()
+
+	expectedStatus := check.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("HTTP %s beklenen durum kodu %d, alınan %d", check.URL, expectedStatus, resp.StatusCode)
+	}
+
+	if check.ExpectedBody != "" {
+		// Sağlık uç noktaları küçüktür; çok büyük bir gövdeyi belleğe almamak için sınır koy.
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return fmt.Errorf("HTTP gövdesi okunamadı (%s): %w", check.URL, err)
+		}
+		if !strings.Contains(string(body), check.ExpectedBody) {
+			return fmt.Errorf("HTTP %s gövdesi beklenen '%s' metnini içermiyor", check.URL, check.ExpectedBody)
+		}
+	}
+	return nil
+}
+
+// checkHealth, yapılandırmadaki tüm sağlık kontrollerini çalıştırır.
+// Bir denemede tüm kontroller başarılı olursa nil döner. Aksi halde
+// 'Retries' kadar ve 'Deadline' süresini aşmayacak şekilde tekrar dener.
+func (p *Poller) checkHealth() error {
+	hc := p.cfg.Health
+	if len(hc.Checks) == 0 {
+		return nil // Sağlık kontrolü yapılandırılmamış.
+	}
+
+	retries := hc.Retries
+	if retries <= 0 {
+		retries = defaultHealthRetries
+	}
+	interval := defaultHealthInterval
+	if hc.IntervalSeconds > 0 {
+		interval = time.Duration(hc.IntervalSeconds) * time.Second
+	}
+	deadline := defaultHealthDeadline
+	if hc.DeadlineSeconds > 0 {
+		deadline = time.Duration(hc.DeadlineSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	var lastErr error
+	for attempt := 1; attempt <= retries; attempt++ {
+		lastErr = p.probeAll(ctx)
+		if lastErr == nil {
+			p.log.Printf("[Health] Tüm sağlık kontrolleri başarılı (deneme %d/%d).", attempt, retries)
+			return nil
+		}
+		p.log.Printf("[Health] Sağlık kontrolü başarısız (deneme %d/%d): %v", attempt, retries, lastErr)
+
+		if attempt == retries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("sağlık kontrolü süre sınırı (%v) aşıldı: %w", deadline, lastErr)
+		case <-time.After(interval):
+		}
+	}
+	return fmt.Errorf("sağlık kontrolü %d denemede başarısız oldu: %w", retries, lastErr)
+}
+
+// probeAll, tüm kontrolleri sırayla çalıştırır ve ilk hatada durur.
+func (p *Poller) probeAll(ctx context.Context) error {
+	for _, check := range p.cfg.Health.Checks {
+		if err := p.prober.Probe(ctx, check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
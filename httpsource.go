@@ -0,0 +1,407 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Model kaynakları (Config.Source).
+const (
+	SourceS3   = "s3"   // Varsayılan: AWS S3 veya S3 uyumlu depo
+	SourceHTTP = "http" // HTTP(S) artifact sunucusu
+)
+
+// httpResponseTimeout, bir HTTP isteğinde yanıt başlıklarının gelmesi için
+// beklenecek en uzun süredir. Gövdenin indirilmesi için bir süre sınırı yoktur.
+const httpResponseTimeout = 60 * time.Second
+
+// lastModifiedETagPrefix, ETag döndürmeyen sunucular için Last-Modified ve
+// boyuttan türetilen revizyon kimliklerinin ön ekidir (örn: "lm-1700000000-1024").
+const lastModifiedETagPrefix = "lm-"
+
+// HTTPSourceConfig, modelin bir HTTP(S) artifact sunucusundan alınması için
+// gereken ayarlardır. Parola, token ve başlık değerlerindeki $DEĞİŞKEN ifadeleri
+// ortam değişkenleriyle değiştirilir; böylece sırlar config.json'a yazılmaz.
+type HTTPSourceConfig struct {
+	URL         string            `json:"url"`          // Modelin adresi (örn: "https://artifacts.local/models/latest.bin")
+	Username    string            `json:"username"`     // Basic auth kullanıcı adı
+	Password    string            `json:"password"`     // Basic auth parolası (örn: "$ARTIFACT_PASSWORD")
+	BearerToken string            `json:"bearer_token"` // "Authorization: Bearer ..." token'ı
+	Headers     map[string]string `json:"headers"`      // Her isteğe eklenecek başlıklar
+	CABundle    string            `json:"ca_bundle"`    // Sunucunun TLS sertifikası için PEM CA dosyası
+}
+
+// String, yapılandırma loglanırken parola, token ve başlık değerlerini gizler.
+func (c HTTPSourceConfig) String() string {
+	if c.URL == "" {
+		return "{}"
+	}
+	names := make([]string, 0, len(c.Headers))
+	for name := range c.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("{URL:%s Username:%s Auth:%t Headers:%v CABundle:%s}",
+		c.URL, c.Username, c.Password != "" || c.BearerToken != "", names, c.CABundle)
+}
+
+// RealHTTPClient, S3Client arayüzünün modeli bir HTTP(S) sunucusundan alan
+// implementasyonudur. Revizyon, ETag (yoksa Last-Modified ve boyut) ile izlenir.
+// Anahtarlar (key) temel adrese ('http.url') göre çözülür; boş anahtar temel
+// adresin kendisidir. '<url>.sha256' ve '<url>.sig' yan dosyaları S3'teki gibi çalışır.
+type RealHTTPClient struct {
+	client     *http.Client
+	base       *url.URL
+	cfg        HTTPSourceConfig
+	stagingDir string // İndirmelerin tamamlanana kadar yazıldığı dizin
+}
+
+// NewRealHTTPClient, 'cfg.HTTP' ayarlarıyla yeni bir RealHTTPClient oluşturur.
+// İndirmeler önce 'stagingDir' altına yazılır.
+func NewRealHTTPClient(cfg *Config, stagingDir string) (*RealHTTPClient, error) {
+	if cfg.HTTP.URL == "" {
+		return nil, fmt.Errorf("http kaynağı için 'http.url' gerekli")
+	}
+	if cfg.S3Prefix != "" || cfg.S3VersionID != "" {
+		return nil, fmt.Errorf("http kaynağında prefix izleme ve sürüm sabitleme desteklenmiyor")
+	}
+	base, err := url.Parse(cfg.HTTP.URL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("geçersiz http.url: '%s'", cfg.HTTP.URL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = httpResponseTimeout
+	if cfg.HTTP.CABundle != "" {
+		pem, err := os.ReadFile(cfg.HTTP.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("CA dosyası okunamadı (%s): %w", cfg.HTTP.CABundle, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA dosyasında sertifika bulunamadı (%s)", cfg.HTTP.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	expanded := cfg.HTTP
+	expanded.Password = os.ExpandEnv(cfg.HTTP.Password)
+	expanded.BearerToken = os.ExpandEnv(cfg.HTTP.BearerToken)
+	expanded.Headers = make(map[string]string, len(cfg.HTTP.Headers))
+	for name, value := range cfg.HTTP.Headers {
+		expanded.Headers[name] = os.ExpandEnv(value)
+	}
+
+	h := &RealHTTPClient{
+		base:       base,
+		cfg:        expanded,
+		stagingDir: stagingDir,
+	}
+	h.client = &http.Client{Transport: transport, CheckRedirect: h.checkRedirect}
+	return h, nil
+}
+
+// checkRedirect, yönlendirme başka bir sunucuya (veya şemaya) gidiyorsa
+// yapılandırılan başlıkları ve kimlik bilgilerini istekten çıkarır.
+func (h *RealHTTPClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("10 yönlendirmeden sonra durduruldu")
+	}
+	if !h.sameOrigin(req.URL) {
+		for name := range h.cfg.Headers {
+			req.Header.Del(name)
+		}
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// sameOrigin, adresin temel adresle aynı şema ve sunucuda olup olmadığını döndürür.
+func (h *RealHTTPClient) sameOrigin(u *url.URL) bool {
+	return u.Scheme == h.base.Scheme && u.Host == h.base.Host
+}
+
+// resolve, bir anahtarı (boş, göreli veya tam adres) tam adrese çevirir.
+func (h *RealHTTPClient) resolve(key string) (*url.URL, error) {
+	if key == "" {
+		return h.base, nil
+	}
+	ref, err := url.Parse(key)
+	if err != nil {
+		return nil, fmt.Errorf("geçersiz adres '%s': %w", key, err)
+	}
+	return h.base.ResolveReference(ref), nil
+}
+
+// do, yapılandırılan başlıklar ve kimlik bilgileriyle bir istek gönderir.
+// Kimlik bilgileri sadece temel adresle aynı şema ve sunucuya gönderilir; paket
+// manifestosu veya bir yönlendirme başka bir sunucuyu (örn: CDN) ya da şifresiz
+// 'http://' adresini gösterse bile sırlar sızmaz.
+func (h *RealHTTPClient) do(method, key string, header http.Header) (*http.Response, *url.URL, error) {
+	u, err := h.resolve(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if h.sameOrigin(u) {
+		for name, value := range h.cfg.Headers {
+			req.Header.Set(name, value)
+		}
+		if h.cfg.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+h.cfg.BearerToken)
+		} else if h.cfg.Username != "" {
+			req.SetBasicAuth(h.cfg.Username, h.cfg.Password)
+		}
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP %s (%s) hatası: %w", method, u.Redacted(), err)
+	}
+	return resp, u, nil
+}
+
+// keyFor, bir adresin revizyon bilgisinde saklanacak anahtarını döndürür.
+// Temel adresle aynı sunucudaki adresler için sadece yol kullanılır; böylece
+// yan dosya ('<key>.sha256') ve paket dosyası anahtarları S3'teki gibi
+// 'path' paketiyle türetilebilir ve temel adrese göre tekrar çözülür.
+func (h *RealHTTPClient) keyFor(u *url.URL) string {
+	if !h.sameOrigin(u) || u.RawQuery != "" {
+		return u.String()
+	}
+	return u.EscapedPath()
+}
+
+// HeadObject, adresin güncel revizyonunu HEAD isteğiyle öğrenir. HEAD
+// desteklemeyen sunucularda GET yapılır ve gövde okunmadan kapatılır.
+// HTTP kaynaklarında sürüm sabitleme yoktur; 'bucket' ve 'versionID' kullanılmaz.
+func (h *RealHTTPClient) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	resp, u, err := h.do(http.MethodHead, key, nil)
+	if err != nil {
+		return ObjectVersion{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		if resp, u, err = h.do(http.MethodGet, key, nil); err != nil {
+			return ObjectVersion{}, err
+		}
+		resp.Body.Close()
+	}
+	if err := httpStatusError(resp, u); err != nil {
+		return ObjectVersion{}, err
+	}
+	return httpObjectVersion(h.keyFor(u), resp)
+}
+
+// ExpectedSHA256, revizyon için yayınlanmış SHA-256 özetini bulur: önce
+// 'X-Checksum-Sha256' yanıt başlığına (Artifactory, Nexus vb.), sonra
+// '<url>.sha256' yan dosyasına bakılır.
+func (h *RealHTTPClient) ExpectedSHA256(bucket string, obj ObjectVersion) (string, error) {
+	return lookupSHA256(obj, func(key string) ([]byte, error) {
+		return h.FetchObject(bucket, key)
+	})
+}
+
+// ListObjects, HTTP kaynaklarında desteklenmez (dizin listesi standart değildir).
+func (h *RealHTTPClient) ListObjects(bucket, prefix string) ([]ObjectVersion, error) {
+	return nil, fmt.Errorf("http kaynağında listeleme (prefix izleme) desteklenmiyor")
+}
+
+// FetchObject, küçük bir dosyanın (imza, özet, manifesto) içeriğini belleğe okur.
+func (h *RealHTTPClient) FetchObject(bucket, key string) ([]byte, error) {
+	resp, u, err := h.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := httpStatusError(resp, u); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP yanıtı okunamadı (%s): %w", u.Redacted(), err)
+	}
+	return content, nil
+}
+
+// DownloadObject, dosyayı S3 istemcisiyle aynı şekilde önce staging'e indirir,
+// özetini akış sırasında hesaplar ve tamamlandığında hedefe taşır. Bağlantı
+// koparsa bir sonraki denemede 'Range' isteğiyle kaldığı yerden devam edilir.
+func (h *RealHTTPClient) DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error) {
+	return stageResumable(h.stagingDir, destinationPath, expectedSHA256, h.rangeFetcher(obj.Key, ""))
+}
+
+// rangeFetcher, stageResumable için 'key' adresini (gerekirse 'Range' ile)
+// indiren fonksiyonu döndürür. 'pinETag' veya yarım dosyanın ETag'i verilmişse
+// yanıtın aynı revizyona ait olduğu doğrulanır; değilse errStalePartial döner.
+func (h *RealHTTPClient) rangeFetcher(key, pinETag string) rangeFetcher {
+	return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		if ifMatch == "" {
+			ifMatch = pinETag
+		}
+		header := make(http.Header)
+		if offset > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+		if since, ok := lastModifiedFromETag(ifMatch); ok {
+			// ETag'siz sunucularda 'If-Range' ile Last-Modified değişmişse sunucu
+			// parçayı değil dosyanın tamamını (200) gönderir; bu aşağıda reddedilir.
+			if offset > 0 {
+				header.Set("If-Range", since.UTC().Format(http.TimeFormat))
+			}
+		} else if ifMatch != "" {
+			header.Set("If-Match", `"`+ifMatch+`"`)
+		}
+
+		resp, u, err := h.do(http.MethodGet, key, header)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+			resp.Body.Close()
+			return nil, "", 0, fmt.Errorf("HTTP GET (%s): %w", u.Redacted(), errStalePartial)
+		case offset > 0 && resp.StatusCode == http.StatusOK:
+			// Sunucu 'Range' desteklemiyor ve dosyayı baştan gönderiyor.
+			resp.Body.Close()
+			return nil, "", 0, fmt.Errorf("HTTP GET (%s): sunucu kısmi indirmeyi desteklemiyor: %w", u.Redacted(), errStalePartial)
+		}
+		if err := httpStatusError(resp, u); err != nil {
+			resp.Body.Close()
+			return nil, "", 0, err
+		}
+
+		obj, err := httpObjectVersion(h.keyFor(u), resp)
+		if err != nil {
+			resp.Body.Close()
+			return nil, "", 0, err
+		}
+		// If-Match'i yok sayan sunucular ve Last-Modified'dan türetilen kimlikler için.
+		if ifMatch != "" && obj.ETag != ifMatch {
+			resp.Body.Close()
+			return nil, "", 0, fmt.Errorf("HTTP GET (%s): %w", u.Redacted(), errStalePartial)
+		}
+
+		total := resp.ContentLength
+		if cr := resp.Header.Get("Content-Range"); cr != "" {
+			if i := strings.LastIndex(cr, "/"); i >= 0 {
+				if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+					total = n
+				}
+			}
+		}
+		return resp.Body, obj.ETag, max(total, 0), nil
+	}
+}
+
+// GetIfChanged, ConditionalFetcher arayüzünü uygular: adres 'If-None-Match'
+// (Last-Modified'dan türetilmiş kimliklerde 'If-Modified-Since') ile istenir.
+func (h *RealHTTPClient) GetIfChanged(bucket, key, etag string) (OpenObject, error) {
+	header := make(http.Header)
+	if since, ok := lastModifiedFromETag(etag); ok {
+		header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+	} else if etag != "" {
+		header.Set("If-None-Match", `"`+etag+`"`)
+	}
+	resp, u, err := h.do(http.MethodGet, key, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, ErrNotModified
+	}
+	if err := httpStatusError(resp, u); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	obj, err := httpObjectVersion(h.keyFor(u), resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if obj.ETag == etag {
+		// Koşullu istekleri desteklemeyen sunucu aynı revizyonu tekrar gönderdi.
+		resp.Body.Close()
+		return nil, ErrNotModified
+	}
+	return &stagedOpenObject{
+		obj:        obj,
+		etag:       obj.ETag,
+		body:       resp.Body,
+		stagingDir: h.stagingDir,
+		ranged:     h.rangeFetcher(obj.Key, obj.ETag),
+	}, nil
+}
+
+// httpStatusError, başarısız bir HTTP yanıtını hataya çevirir. 404 ve 410
+// ErrObjectNotFound olarak döner.
+func httpStatusError(resp *http.Response, u *url.URL) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("HTTP (%s): %w", u.Redacted(), ErrObjectNotFound)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("HTTP (%s) beklenmeyen yanıt: %s", u.Redacted(), resp.Status)
+	}
+	return nil
+}
+
+// httpObjectVersion, bir HTTP yanıtının başlıklarından revizyon bilgisini oluşturur.
+// Sunucu ETag döndürmüyorsa kimlik Last-Modified ve boyuttan türetilir; ikisi de
+// yoksa değişiklik algılanamayacağı için hata döner.
+func httpObjectVersion(key string, resp *http.Response) (ObjectVersion, error) {
+	obj := ObjectVersion{
+		Key:  key,
+		ETag: strings.Trim(strings.TrimPrefix(resp.Header.Get("ETag"), "W/"), `"`),
+		Size: max(resp.ContentLength, 0),
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = lm
+	}
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				obj.Size = n
+			}
+		}
+	}
+	if sum := resp.Header.Get("X-Checksum-Sha256"); sum != "" {
+		obj.Metadata = map[string]string{"sha256": sum}
+	}
+
+	if obj.ETag == "" {
+		if obj.LastModified.IsZero() {
+			return ObjectVersion{}, fmt.Errorf("sunucu ETag veya Last-Modified döndürmüyor, değişiklik algılanamaz (%s)", key)
+		}
+		obj.ETag = fmt.Sprintf("%s%d-%d", lastModifiedETagPrefix, obj.LastModified.Unix(), obj.Size)
+	}
+	return obj, nil
+}
+
+// lastModifiedFromETag, Last-Modified'dan türetilmiş bir revizyon kimliğindeki zamanı döndürür.
+func lastModifiedFromETag(etag string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(etag, lastModifiedETagPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	unix, _, _ := strings.Cut(rest, "-")
+	sec, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newArtifactServer, ETag'li bir model ve '.sha256' yan dosyası sunan sahte
+// bir artifact sunucusu başlatır. 'Authorization' ve 'X-Team' başlıkları zorunludur.
+func newArtifactServer(t *testing.T, content *[]byte, etag *string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gizli" || r.Header.Get("X-Team") != "vision" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/models/latest.bin":
+		case "/models/latest.bin.sha256":
+			fmt.Fprintf(w, "%s  latest.bin\n", sha256Hex(*content))
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"`+*etag+`"`)
+		if r.Header.Get("If-None-Match") == `"`+*etag+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		http.ServeContent(w, r, "latest.bin", time.Time{}, strings.NewReader(string(*content)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestHTTPClient(t *testing.T, url string) *RealHTTPClient {
+	t.Setenv("ARTIFACT_TOKEN", "gizli")
+	client, err := NewRealHTTPClient(&Config{HTTP: HTTPSourceConfig{
+		URL:         url,
+		BearerToken: "$ARTIFACT_TOKEN",
+		Headers:     map[string]string{"X-Team": "vision"},
+	}}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealHTTPClient() hata döndürdü: %v", err)
+	}
+	return client
+}
+
+func TestRealHTTPClient_HeadAndDownload(t *testing.T) {
+	content, etag := []byte("model ağırlıkları"), "v1"
+	server := newArtifactServer(t, &content, &etag)
+	client := newTestHTTPClient(t, server.URL+"/models/latest.bin")
+
+	obj, err := client.HeadObject("", "", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	if obj.ETag != "v1" || obj.Key != "/models/latest.bin" || obj.Size != int64(len(content)) {
+		t.Errorf("Beklenmeyen revizyon bilgisi: %+v", obj)
+	}
+
+	expected, err := client.ExpectedSHA256("", obj)
+	if err != nil || expected != sha256Hex(content) {
+		t.Fatalf("Özet yan dosyadan okunmalıydı, alınan: %q, %v", expected, err)
+	}
+	dest := filepath.Join(t.TempDir(), "model-v1.bin")
+	digest, err := client.DownloadObject("", obj, dest, expected)
+	if err != nil {
+		t.Fatalf("DownloadObject() hata döndürdü: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if digest != expected || string(got) != string(content) {
+		t.Errorf("Model hedefe yazılmalıydı (özet: %s)", digest)
+	}
+
+	if _, err := client.HeadObject("", "missing.bin", ""); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("ErrObjectNotFound bekleniyordu, alınan: %v", err)
+	}
+}
+
+// TestRealHTTPClient_LastModified, ETag döndürmeyen ve HEAD desteklemeyen bir
+// sunucuda revizyonun Last-Modified ve boyuttan türetildiğini, koşullu isteğin
+// 'If-Modified-Since' ile yapıldığını ve kimlik bilgilerinin Basic auth ile
+// gönderildiğini test eder.
+func TestRealHTTPClient_LastModified(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "edge" || pass != "parola" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.ServeContent(w, r, "", modified, strings.NewReader("model"))
+	}))
+	defer server.Close()
+
+	client, err := NewRealHTTPClient(&Config{HTTP: HTTPSourceConfig{URL: server.URL + "/model.bin", Username: "edge", Password: "parola"}}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealHTTPClient() hata döndürdü: %v", err)
+	}
+	obj, err := client.HeadObject("", "", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	want := fmt.Sprintf("lm-%d-5", modified.Unix())
+	if obj.ETag != want {
+		t.Errorf("ETag '%s' olmalıydı, alınan: '%s'", want, obj.ETag)
+	}
+	if _, err := client.GetIfChanged("", "", obj.ETag); !errors.Is(err, ErrNotModified) {
+		t.Errorf("ErrNotModified bekleniyordu, alınan: %v", err)
+	}
+}
+
+// TestRealHTTPClient_LastModifiedResume, ETag döndürmeyen bir sunucuda dosya
+// değiştiğinde kaldığı yerden devam eden indirmenin ('If-Range') ve baştan
+// başlayan indirmenin eski revizyonla karışmadığını test eder.
+func TestRealHTTPClient_LastModifiedResume(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", modified, strings.NewReader("model"))
+	}))
+	defer server.Close()
+
+	client, err := NewRealHTTPClient(&Config{HTTP: HTTPSourceConfig{URL: server.URL + "/model.bin"}}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealHTTPClient() hata döndürdü: %v", err)
+	}
+	obj, err := client.HeadObject("", "", "")
+	if err != nil {
+		t.Fatalf("HeadObject() hata döndürdü: %v", err)
+	}
+	fetch := client.rangeFetcher(obj.Key, obj.ETag)
+
+	body, etag, total, err := fetch(2, "")
+	if err != nil {
+		t.Fatalf("Değişmeyen dosya kaldığı yerden indirilmeliydi: %v", err)
+	}
+	body.Close()
+	if etag != obj.ETag || total != 5 {
+		t.Errorf("Beklenmeyen revizyon: %s, boyut: %d", etag, total)
+	}
+
+	modified = modified.Add(time.Hour)
+	if _, _, _, err := fetch(2, ""); !errors.Is(err, errStalePartial) {
+		t.Errorf("Değişen dosyada kısmi indirme errStalePartial döndürmeliydi, alınan: %v", err)
+	}
+	if _, _, _, err := fetch(0, ""); !errors.Is(err, errStalePartial) {
+		t.Errorf("Değişen dosya sabitlenen revizyon yerine indirilmemeliydi, alınan: %v", err)
+	}
+}
+
+// TestRealHTTPClient_AuthStaysOnHost, kimlik bilgilerinin başka bir sunucuya
+// (örn: paket manifestosundaki bir CDN adresi) gönderilmediğini test eder.
+func TestRealHTTPClient_AuthStaysOnHost(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Team") != "" {
+			t.Errorf("Kimlik bilgileri başka sunucuya gönderilmemeliydi: %v", r.Header)
+		}
+		fmt.Fprint(w, "tokenizer")
+	}))
+	defer other.Close()
+	content, etag := []byte("model"), "v1"
+	client := newTestHTTPClient(t, newArtifactServer(t, &content, &etag).URL+"/models/latest.bin")
+
+	got, err := client.FetchObject("", other.URL+"/tokenizer.json")
+	if err != nil || string(got) != "tokenizer" {
+		t.Errorf("Başka sunucudaki dosya okunmalıydı, alınan: %q, %v", got, err)
+	}
+}
+
+// TestRealHTTPClient_AuthNotRedirected, başka bir sunucuya yönlendirilen
+// isteklerden ve aynı sunucunun şifresiz ('http://') adresine yapılan
+// isteklerden kimlik bilgilerinin çıkarıldığını test eder.
+func TestRealHTTPClient_AuthNotRedirected(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Team") != "" {
+			t.Errorf("Kimlik bilgileri başka sunucuya gönderilmemeliydi: %v", r.Header)
+		}
+		fmt.Fprint(w, "model")
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/cdn/latest.bin", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	client := newTestHTTPClient(t, origin.URL+"/models/latest.bin")
+	if got, err := client.FetchObject("", ""); err != nil || string(got) != "model" {
+		t.Errorf("Yönlendirilen dosya okunmalıydı, alınan: %q, %v", got, err)
+	}
+
+	// Temel adres 'https://' iken aynı sunucunun 'http://' adresine sır gönderilmez.
+	secure := newTestHTTPClient(t, strings.Replace(other.URL, "http://", "https://", 1)+"/models/latest.bin")
+	if _, err := secure.FetchObject("", other.URL+"/models/tokenizer.json"); err != nil {
+		t.Errorf("Şifresiz adresteki dosya okunmalıydı: %v", err)
+	}
+}
+
+// TestPoller_HTTPSource, "http" kaynağıyla uçtan uca bir döngüyü test eder:
+// model koşullu GET ile alınır, yan dosyadaki özetle doğrulanır ve değişmediğinde
+// (304) tekrar indirilmez.
+func TestPoller_HTTPSource(t *testing.T) {
+	dir := t.TempDir()
+	content, etag := []byte("ilk model"), "v1"
+	server := newArtifactServer(t, &content, &etag)
+	cfg := &Config{
+		DataDir:          dir,
+		Source:           SourceHTTP,
+		DeployScriptPath: "deploy.sh",
+		S3ConditionalGet: true,
+		RequireSHA256:    true,
+	}
+	client := newTestHTTPClient(t, server.URL+"/models/latest.bin")
+	cfg.HTTP = client.cfg
+	mockDeploy := &MockDeployer{}
+	p := NewPoller(cfg, client, mockDeploy, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, filepath.Join(dir, "active_model_link"))
+
+	// 1. İlk çalışma: bilinen revizyon yok, HEAD ile okunup kaydedilir.
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	// 2. Değişiklik yok (304).
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	// 3. Yeni model koşullu GET yanıtından kaydedilir.
+	content, etag = []byte("ikinci model"), "v2"
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if p.GetStatus() != "v2" {
+		t.Errorf("Son ETag 'v2' olmalıydı, alınan: '%s'", p.GetStatus())
+	}
+	if len(mockDeploy.Calls) != 2 {
+		t.Errorf("Sadece yeni model deploy edilmeliydi: %v", mockDeploy.Calls)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, modelsSubdir, "model-v2.bin"))
+	if string(got) != "ikinci model" {
+		t.Errorf("Yeni model indirilmeliydi, alınan: %q", got)
+	}
+}
@@ -1,55 +1,102 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 )
 
 // RealS3Client, S3Client arayüzünün AWS SDK kullanarak gerçek bir
 // S3 servisiyle konuşan implementasyonudur.
 type RealS3Client struct {
 	client     *s3.Client
-	downloader *manager.Downloader
+	stagingDir string // İndirmelerin tamamlanana kadar yazıldığı dizin
 }
 
 // NewRealS3Client, varsayılan AWS kimlik bilgilerini (ortam değişkenleri, IAM rolü vb.)
-// kullanarak yeni bir RealS3Client oluşturur.
-func NewRealS3Client() (*RealS3Client, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+// kullanarak yeni bir RealS3Client oluşturur. İndirmeler önce 'stagingDir' altına yazılır.
+// 'cfg' içinde özel bir uç nokta verilmişse istemci AWS yerine o S3 uyumlu depoyla konuşur.
+func NewRealS3Client(cfg *Config, stagingDir string) (*RealS3Client, error) {
+	awsCfg, err := loadAWSConfig(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("aws config yüklenemedi: %w", err)
+		return nil, err
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
-	downloader := manager.NewDownloader(s3Client)
+	s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.S3Endpoint)
+		}
+		// MinIO gibi depolar genellikle sanal host (bucket.host) adreslemeyi desteklemez.
+		o.UsePathStyle = cfg.S3UsePathStyle
+	})
 
 	return &RealS3Client{
 		client:     s3Client,
-		downloader: downloader,
+		stagingDir: stagingDir,
 	}, nil
 }
 
+// loadAWSConfig, varsayılan AWS kimlik bilgilerini yükler ve yapılandırmadaki
+// bölge ile CA dosyasını uygular. S3 ve SQS istemcileri aynı ayarları kullanır.
+func loadAWSConfig(cfg *Config) (aws.Config, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if cfg.S3Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(cfg.S3Region))
+	}
+	if cfg.S3CABundle != "" {
+		// Şirket içi depolar genellikle kendi CA'ları ile imzalanmış sertifika kullanır.
+		caBundle, err := os.ReadFile(cfg.S3CABundle)
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("CA dosyası okunamadı (%s): %w", cfg.S3CABundle, err)
+		}
+		loadOpts = append(loadOpts, config.WithCustomCABundle(bytes.NewReader(caBundle)))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(), loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("aws config yüklenemedi: %w", err)
+	}
+	return awsCfg, nil
+}
+
 // RealLinker, Linker arayüzünün os paketini kullanarak gerçek sembolik bağları
 // yöneten implementasyonudur.
 type RealLinker struct{}
 
 // Set, hedefi gösteren bir sembolik bağ oluşturur.
-// Eğer o isimde bir dosya/link zaten varsa, önce onu siler.
+// Yeni bağ önce geçici bir isimle oluşturulur ve ardından rename(2) ile eskisinin
+// üzerine taşınır. rename atomik olduğu için 'linkName'i okuyan bir süreç her an
+// ya eski ya da yeni modeli görür; bağın hiç olmadığı bir an yaşanmaz.
 func (rl *RealLinker) Set(target, linkName string) error {
-	// Önce mevcut linki (varsa) kaldır.
-	err := os.Remove(linkName)
-	// Eğer dosya zaten yoksa bu bir hata değildir, devam et.
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	tmpLink := fmt.Sprintf("%s.tmp-%d", linkName, time.Now().UnixNano())
+	if err := os.Symlink(target, tmpLink); err != nil {
+		return fmt.Errorf("geçici sembolik bağ oluşturulamadı (%s): %w", tmpLink, err)
+	}
+
+	if err := os.Rename(tmpLink, linkName); err != nil {
+		os.Remove(tmpLink) // Geçici bağı geride bırakma.
+		return fmt.Errorf("sembolik bağ yerine taşınamadı (%s): %w", linkName, err)
 	}
-	return os.Symlink(target, linkName)
+
+	// Rename işleminin elektrik kesintisine karşı kalıcı olması için üst dizini fsync et.
+	syncDir(filepath.Dir(linkName))
+	return nil
 }
 
 // Get, bir sembolik bağın hedefini okur.
@@ -75,39 +122,231 @@ func (rd *RealDeployer) Run(scriptPath string, args ...string) error {
 	return nil
 }
 
-// HeadObject, S3'teki bir nesnenin metadata'sını (özellikle ETag) almak için
-// AWS SDK'sını kullanır.
-func (r *RealS3Client) HeadObject(bucket, key string) (string, error) {
-	output, err := r.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
-		Bucket: &bucket,
-		Key:    &key,
-	})
+// Output, script'i çalıştırır ve standart çıktısını döndürür.
+// Script hatayla biterse, hata mesajı stderr çıktısını da içerir.
+func (rd *RealDeployer) Output(scriptPath string, args ...string) (string, error) {
+	cmd := exec.Command(scriptPath, args...)
+	output, err := cmd.Output()
+
 	if err != nil {
-		return "", fmt.Errorf("S3 HeadObject (%s/%s) hatası: %w", bucket, key, err)
+		stderr := ""
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+		}
+		return "", fmt.Errorf("script '%s %s' hatayla sonlandı: %w. Çıktı: %s", scriptPath, strings.Join(args, " "), err, stderr)
 	}
 
-	// S3 ETag'leri genellikle çift tırnak içinde gelir ("..."), bunları temizliyoruz.
-	etag := strings.Trim(*output.ETag, "\"")
-	return etag, nil
+	return string(output), nil
 }
 
-// DownloadObject, S3'teki bir nesneyi belirtilen yola indirmek için
-// AWS SDK'sının 's3manager'ını kullanır. Bu, büyük dosyalar için daha verimlidir.
-func (r *RealS3Client) DownloadObject(bucket, key, destinationPath string) error {
-	file, err := os.Create(destinationPath)
+// HeadObject, S3'teki bir nesnenin revizyon bilgilerini (VersionID, ETag, boyut,
+// metadata) almak için AWS SDK'sını kullanır. 'versionID' boş değilse o revizyon sorgulanır.
+// S3'ün kendi SHA-256 özeti de aynı istekte istenir (ChecksumMode).
+func (r *RealS3Client) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       &bucket,
+		Key:          &key,
+		ChecksumMode: types.ChecksumModeEnabled,
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	output, err := r.client.HeadObject(context.TODO(), input)
 	if err != nil {
-		return fmt.Errorf("indirme hedefi oluşturulamadı (%s): %w", destinationPath, err)
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return ObjectVersion{}, fmt.Errorf("S3 HeadObject (%s/%s): %w", bucket, key, ErrObjectNotFound)
+		}
+		return ObjectVersion{}, fmt.Errorf("S3 HeadObject (%s/%s) hatası: %w", bucket, key, err)
+	}
+
+	return objectVersion(key, output.ETag, output.VersionId, output.LastModified, output.ContentLength,
+		output.Metadata, output.ChecksumSHA256, output.ChecksumType)
+}
+
+// objectVersion, HeadObject veya GetObject yanıtındaki alanlardan revizyon bilgisini oluşturur.
+func objectVersion(key string, etag, versionID *string, lastModified *time.Time, size *int64,
+	metadata map[string]string, checksumSHA256 *string, checksumType types.ChecksumType) (ObjectVersion, error) {
+	obj := ObjectVersion{
+		Key: key,
+		// S3 ETag'leri genellikle çift tırnak içinde gelir ("..."), bunları temizliyoruz.
+		ETag:         strings.Trim(aws.ToString(etag), "\""),
+		VersionID:    aws.ToString(versionID),
+		LastModified: aws.ToTime(lastModified),
+		Size:         aws.ToInt64(size),
+		Metadata:     metadata,
+	}
+	// Versiyonlama kapalı bucket'larda S3 VersionID olarak "null" döndürür.
+	if obj.VersionID == "null" {
+		obj.VersionID = ""
+	}
+	// Multipart yüklemelerde ChecksumSHA256 parçaların özetidir (COMPOSITE), dosyanın değil.
+	if checksumSHA256 != nil && checksumType == types.ChecksumTypeFullObject {
+		sum, err := normalizeSHA256(*checksumSHA256)
+		if err != nil {
+			return ObjectVersion{}, err
+		}
+		obj.ChecksumSHA256 = sum
+	}
+	return obj, nil
+}
+
+// ExpectedSHA256, S3'teki revizyon için yayınlanmış SHA-256 özetini bulur.
+// Sırasıyla şunlara bakılır:
+//  1. 'x-amz-meta-sha256' kullanıcı metadata'sı
+//  2. S3'ün kendi 'ChecksumSHA256' değeri (sadece tüm nesneyi kapsıyorsa)
+//  3. '<key>.sha256' yan dosyası
+//
+// Hiçbiri yoksa boş string döner. Metadata ve checksum HeadObject'ten gelen
+// revizyon bilgisinden okunur; ek bir istek yapılmaz.
+func (r *RealS3Client) ExpectedSHA256(bucket string, obj ObjectVersion) (string, error) {
+	return lookupSHA256(obj, func(key string) ([]byte, error) {
+		return r.FetchObject(bucket, key)
+	})
+}
+
+// ListObjects, 'prefix' altındaki tüm nesneleri ListObjectsV2 ile (sayfa sayfa) listeler.
+func (r *RealS3Client) ListObjects(bucket, prefix string) ([]ObjectVersion, error) {
+	var objects []ObjectVersion
+	paginator := s3.NewListObjectsV2Paginator(r.client, &s3.ListObjectsV2Input{
+		Bucket: &bucket,
+		Prefix: &prefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("S3 ListObjectsV2 (%s/%s) hatası: %w", bucket, prefix, err)
+		}
+		for _, item := range page.Contents {
+			objects = append(objects, ObjectVersion{
+				Key:          aws.ToString(item.Key),
+				ETag:         strings.Trim(aws.ToString(item.ETag), "\""),
+				LastModified: aws.ToTime(item.LastModified),
+				Size:         aws.ToInt64(item.Size),
+			})
+		}
 	}
-	defer file.Close()
+	return objects, nil
+}
 
-	_, err = r.downloader.Download(context.TODO(), file, &s3.GetObjectInput{
+// maxFetchSize, FetchObject ile belleğe okunabilecek en büyük nesne boyutudur.
+// İmza ve özet dosyaları birkaç yüz bayttır.
+const maxFetchSize = 1 << 20
+
+// FetchObject, küçük bir nesnenin içeriğini belleğe okur.
+func (r *RealS3Client) FetchObject(bucket, key string) ([]byte, error) {
+	output, err := r.client.GetObject(context.TODO(), &s3.GetObjectInput{
 		Bucket: &bucket,
 		Key:    &key,
 	})
 	if err != nil {
-		os.Remove(destinationPath) // İndirme başarısız olursa, yarım kalan dosyayı sil.
-		return fmt.Errorf("S3 DownloadObject (%s/%s) hatası: %w", bucket, key, err)
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("S3 GetObject (%s/%s): %w", bucket, key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("S3 GetObject (%s/%s) hatası: %w", bucket, key, err)
 	}
+	defer output.Body.Close()
 
-	return nil
+	content, err := io.ReadAll(io.LimitReader(output.Body, maxFetchSize))
+	if err != nil {
+		return nil, fmt.Errorf("S3 nesnesi okunamadı (%s/%s): %w", bucket, key, err)
+	}
+	return content, nil
+}
+
+// DownloadObject, S3'teki bir nesneyi belirtilen yola indirir.
+// Dosya önce staging dizinine indirilir, SHA-256 özeti akış sırasında hesaplanır
+// ve sadece tamamlandığında (ve özet eşleştiğinde) hedefe taşınır.
+// Bağlantı koparsa yarım dosya saklanır; bir sonraki denemede nesnenin ETag'i
+// değişmemişse indirme, ranged GetObject ile kaldığı yerden devam eder.
+// 'obj.VersionID' doluysa tam olarak o revizyon indirilir; indirme sırasında
+// nesnenin üzerine yazılması indirilen içeriği değiştirmez.
+func (r *RealS3Client) DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error) {
+	return stageResumable(r.stagingDir, destinationPath, expectedSHA256, r.rangeFetcher(bucket, obj, ""))
+}
+
+// rangeFetcher, bir nesnenin (ranged) GetObject istekleriyle indirilmesi için
+// stageResumable'ın kullandığı fonksiyonu döndürür. 'pinETag' boş değilse her
+// istek bu ETag'e sabitlenir (If-Match); nesne değişmişse indirme başarısız olur.
+func (r *RealS3Client) rangeFetcher(bucket string, obj ObjectVersion, pinETag string) rangeFetcher {
+	key := obj.Key
+	return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		if ifMatch == "" {
+			ifMatch = pinETag
+		}
+		input := &s3.GetObjectInput{
+			Bucket: &bucket,
+			Key:    &key,
+		}
+		if obj.VersionID != "" {
+			input.VersionId = aws.String(obj.VersionID)
+		}
+		if offset > 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+		}
+		if ifMatch != "" {
+			input.IfMatch = aws.String(ifMatch)
+		}
+
+		output, err := r.client.GetObject(context.TODO(), input)
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "InvalidRange") {
+				return nil, "", 0, fmt.Errorf("S3 GetObject (%s/%s): %w", bucket, key, errStalePartial)
+			}
+			return nil, "", 0, fmt.Errorf("S3 DownloadObject (%s/%s) hatası: %w", bucket, key, err)
+		}
+
+		// Toplam boyut: ranged isteklerde "bytes 100-999/1000" başlığından,
+		// tam isteklerde ContentLength'ten okunur.
+		total := aws.ToInt64(output.ContentLength)
+		if cr := aws.ToString(output.ContentRange); cr != "" {
+			if i := strings.LastIndex(cr, "/"); i >= 0 {
+				if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+					total = n
+				}
+			}
+		}
+		return output.Body, aws.ToString(output.ETag), total, nil
+	}
+}
+
+// GetIfChanged, ConditionalFetcher arayüzünü uygular: nesneyi
+// 'If-None-Match' ile ister ve revizyon bilgisini (ETag, VersionID, boyut,
+// metadata, S3 SHA-256 özeti) aynı yanıttan okur.
+func (r *RealS3Client) GetIfChanged(bucket, key, etag string) (OpenObject, error) {
+	output, err := r.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket:       &bucket,
+		Key:          &key,
+		IfNoneMatch:  aws.String(`"` + etag + `"`),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified {
+			return nil, ErrNotModified
+		}
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("S3 GetObject (%s/%s): %w", bucket, key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("S3 GetObject (%s/%s) hatası: %w", bucket, key, err)
+	}
+
+	obj, err := objectVersion(key, output.ETag, output.VersionId, output.LastModified, output.ContentLength,
+		output.Metadata, output.ChecksumSHA256, output.ChecksumType)
+	if err != nil {
+		output.Body.Close()
+		return nil, err
+	}
+	// S3'ün döndürdüğü (tırnaklı) ETag, devam isteklerinde If-Match için kullanılır.
+	rawETag := aws.ToString(output.ETag)
+	return &stagedOpenObject{
+		obj:        obj,
+		etag:       rawETag,
+		body:       output.Body,
+		stagingDir: r.stagingDir,
+		ranged:     r.rangeFetcher(bucket, obj, rawETag),
+	}, nil
 }
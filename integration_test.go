@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRealLinker_SetReplacesExistingLink, RealLinker'ın mevcut bir bağı
+// yenisiyle değiştirdiğini ve geride geçici dosya bırakmadığını test eder.
+func TestRealLinker_SetReplacesExistingLink(t *testing.T) {
+	dir := t.TempDir()
+	linkName := filepath.Join(dir, "active_model")
+	linker := &RealLinker{}
+
+	// İlk bağ: henüz 'linkName' yok.
+	if err := linker.Set("models/model-v1.bin", linkName); err != nil {
+		t.Fatalf("Set() ilk bağda hata döndürdü: %v", err)
+	}
+	// İkinci bağ: mevcut bağın üzerine yazılmalı.
+	if err := linker.Set("models/model-v2.bin", linkName); err != nil {
+		t.Fatalf("Set() mevcut bağın üzerine yazarken hata döndürdü: %v", err)
+	}
+
+	target, err := linker.Get(linkName)
+	if err != nil {
+		t.Fatalf("Get() hata döndürdü: %v", err)
+	}
+	if target != "models/model-v2.bin" {
+		t.Errorf("Bağ hedefi 'models/model-v2.bin' olmalıydı, ancak '%s' oldu", target)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Dizin okunamadı: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Dizinde sadece 'active_model' kalmalıydı, ancak %d girdi var", len(entries))
+	}
+}
+
+// TestNewRealS3Client_CustomEndpoint, S3 uyumlu bir uç nokta ayarlarının
+// (MinIO vb.) S3 istemcisine aktarıldığını test eder. Ağ bağlantısı gerekmez.
+func TestNewRealS3Client_CustomEndpoint(t *testing.T) {
+	cfg := &Config{
+		S3Endpoint:     "http://localhost:9000",
+		S3UsePathStyle: true,
+		S3Region:       "us-east-1",
+	}
+
+	client, err := NewRealS3Client(cfg, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRealS3Client() hata döndürdü: %v", err)
+	}
+
+	opts := client.client.Options()
+	if opts.BaseEndpoint == nil || *opts.BaseEndpoint != "http://localhost:9000" {
+		t.Errorf("BaseEndpoint 'http://localhost:9000' olmalıydı, alınan: %v", opts.BaseEndpoint)
+	}
+	if !opts.UsePathStyle {
+		t.Error("UsePathStyle açık olmalıydı")
+	}
+	if opts.Region != "us-east-1" {
+		t.Errorf("Region 'us-east-1' olmalıydı, alınan: '%s'", opts.Region)
+	}
+}
+
+// TestNewRealS3Client_MissingCABundle, CA dosyası okunamazsa açık bir hata döndüğünü test eder.
+func TestNewRealS3Client_MissingCABundle(t *testing.T) {
+	cfg := &Config{S3CABundle: filepath.Join(t.TempDir(), "yok.pem")}
+	if _, err := NewRealS3Client(cfg, t.TempDir()); err == nil {
+		t.Error("Olmayan CA dosyası için hata dönmeliydi")
+	}
+}
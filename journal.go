@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JournalPhase, bir dağıtımın hangi aşamaya kadar tamamlandığını belirtir.
+type JournalPhase string
+
+// Dağıtım aşamaları, RunOnce içindeki sırayla.
+const (
+	PhaseDownloaded JournalPhase = "downloaded" // Model indirildi
+	PhaseTested     JournalPhase = "tested"     // `deploy.sh --test` başarılı
+	PhaseLinked     JournalPhase = "linked"     // Sembolik bağ yeni modele çevrildi
+	PhaseReloaded   JournalPhase = "reloaded"   // `deploy.sh --reload` başarılı
+	PhaseSoaking    JournalPhase = "soaking"    // Sağlık kontrolleri geçti, izleme penceresinde
+	PhaseCommitted  JournalPhase = "committed"  // Durum kalıcı olarak kaydedildi
+)
+
+// JournalEntry, dağıtım günlüğündeki (write-ahead journal) tek bir kayıttır.
+type JournalEntry struct {
+	Phase          JournalPhase `json:"phase"`
+	Key            string       `json:"key,omitempty"`
+	ETag           string       `json:"etag"`
+	VersionID      string       `json:"version_id,omitempty"`
+	ModelPath      string       `json:"model_path"`               // Yeni modelin yolu
+	SHA256         string       `json:"sha256,omitempty"`         // Yeni modelin doğrulanmış SHA-256 özeti
+	SignedVersion  uint64       `json:"signed_version,omitempty"` // İmzalı sürüm numarası (imza doğrulaması açıksa)
+	PreviousTarget string       `json:"previous_target"`          // Rollback için eski modelin yolu
+	SoakUntil      time.Time    `json:"soak_until,omitempty"`
+	Time           time.Time    `json:"time"`
+}
+
+// journalFileName, dağıtım günlüğünün state dizini içindeki adıdır.
+const journalFileName = "deploy_journal.log"
+
+// AppendJournal, kaydı günlük dosyasına tek satır JSON olarak ekler ve
+// fsync eder. Kayıt diske yazılmadan bir sonraki aşamaya geçilmez.
+func (s *RealStateStore) AppendJournal(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("günlük kaydı serileştirilemedi: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("dizin oluşturulamadı (%s): %w", s.dir, err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, journalFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("günlük dosyası açılamadı: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("günlük dosyasına yazılamadı: %w", err)
+	}
+	return f.Sync()
+}
+
+// LastJournal, günlükteki son geçerli kaydı döndürür. Çökme anında yarım
+// yazılmış son satır varsa yok sayılır.
+func (s *RealStateStore) LastJournal() (*JournalEntry, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, journalFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("günlük dosyası okunamadı: %w", err)
+	}
+
+	var last *JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		last = &entry
+	}
+	return last, nil
+}
+
+// ClearJournal, günlük dosyasını siler.
+func (s *RealStateStore) ClearJournal() error {
+	err := os.Remove(filepath.Join(s.dir, journalFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	syncDir(s.dir)
+	return nil
+}
+
+// Recover, ajan başlarken (poll döngüsünden önce) dağıtım günlüğünü inceler.
+// Önceki çalışma bir dağıtımın ortasında kesildiyse, kalınan aşamaya göre
+// dağıtımı tamamlar veya eski modele geri döner.
+func (p *Poller) Recover() error {
+	entry, err := p.store.LastJournal()
+	if err != nil {
+		return fmt.Errorf("dağıtım günlüğü okunamadı: %w", err)
+	}
+	if entry == nil {
+		return nil // Yarım kalmış dağıtım yok.
+	}
+
+	p.log.Printf("[Recovery] Yarım kalmış dağıtım bulundu. Revizyon: '%s', Aşama: '%s'", entry.ID(), entry.Phase)
+
+	switch entry.Phase {
+	case PhaseDownloaded, PhaseTested:
+		// Süreç bağ değiştirildikten hemen sonra, PhaseLinked yazılmadan kesilmiş
+		// olabilir; son kayda güvenmeden bağın gösterdiği yere bakılır.
+		if current, err := p.linker.Get(p.activeModelPath); err == nil && current == entry.ModelPath {
+			p.log.Println("[Recovery] Sembolik bağ yeni modele çevrilmiş ama günlüğe yazılmamış.")
+			return p.recoverLinked(entry)
+		}
+		// Sembolik bağa henüz dokunulmamıştı; çalışan sistem eski modelde.
+		// Günlüğü temizlemek yeterli, bir sonraki döngü dağıtımı baştan dener.
+		p.log.Println("[Recovery] Sembolik bağ değişmemiş. Dağıtım bir sonraki döngüde yeniden denenecek.")
+		p.clearJournal()
+		return nil
+
+	case PhaseLinked:
+		return p.recoverLinked(entry)
+
+	case PhaseReloaded:
+		// Servis yeni modelle başladı ama durum kaydedilmeden süreç kesildi.
+		// Sağlık kontrolleri tamamlanmamış olabilir; commit etmeden önce tekrar çalıştır.
+		p.log.Println("[Recovery] Servis yeni modelle başlatılmıştı. Sağlık kontrol ediliyor...")
+		if err := p.checkHealth(); err != nil {
+			p.log.Printf("[Recovery] Servis yeni modelle sağlıksız: %v", err)
+			p.quarantine(entry.ID(), err)
+			if errRollback := p.rollback(entry.PreviousTarget); errRollback != nil {
+				return errRollback
+			}
+			return fmt.Errorf("sağlık kontrolü hatası (rollback yapıldı): %w", err)
+		}
+		return p.startSoak(entry)
+
+	case PhaseSoaking:
+		// İzleme penceresi sürerken ajan yeniden başladı. İzlemeye kaldığı yerden
+		// devam edilir; pencere dolmuşsa bir sonraki döngüde son kontrol yapılıp commit edilir.
+		p.log.Printf("[Recovery] Model izleme penceresinde. İzleme '%s' tarihine kadar devam edecek.", entry.SoakUntil.Format(time.RFC3339))
+		p.mu.Lock()
+		p.soak = entry
+		p.mu.Unlock()
+		// Bağ artık yeni modeli göstermiyorsa, izleme hatasından sonra başlayan bir
+		// rollback yarıda kalmıştır; sağlık kontrolü eski modeli ölçeceği için
+		// izleme sürdürülmez, geri alma bir sonraki döngüde tamamlanır.
+		if current, err := p.linker.Get(p.activeModelPath); err == nil && current != "" && current != entry.ModelPath {
+			p.log.Println("[Recovery] Sembolik bağ yeni modeli göstermiyor. Yarım kalan rollback tamamlanacak.")
+			p.mu.Lock()
+			p.soakFailure = errors.New("izleme sırasında başlayan rollback yarıda kaldı")
+			p.mu.Unlock()
+		}
+		return nil
+
+	default:
+		// PhaseCommitted: dağıtım tamamlanmış, sadece günlük temizlenmemiş.
+		p.clearJournal()
+		return nil
+	}
+}
+
+// recoverLinked, bağın yeni modeli gösterdiği ama servisin yeni modelle
+// başlatıldığının doğrulanmadığı bir dağıtımı kurtarır. Eski model biliniyorsa
+// güvenli tarafta kalıp ona dönülür; bilinmiyorsa dağıtım tamamlanmaya çalışılır.
+func (p *Poller) recoverLinked(entry *JournalEntry) error {
+	if entry.PreviousTarget != "" {
+		p.log.Printf("[Recovery] Servisin yeni modelle başladığı doğrulanmadı. Eski modele ('%s') dönülüyor.", entry.PreviousTarget)
+		if err := p.rollback(entry.PreviousTarget); err != nil {
+			return err
+		}
+		return nil
+	}
+	// Dönülecek bir model yok; dağıtımı tamamlamayı dene.
+	p.log.Println("[Recovery] Eski model bilinmiyor. Servis yeni modelle yeniden başlatılıyor...")
+	if err := p.deploy.Run(p.cfg.DeployScriptPath, "--reload"); err != nil {
+		return fmt.Errorf("KRİTİK HATA! Kurtarma sırasında servis yeniden başlatılamadı: %w", err)
+	}
+	if err := p.checkHealth(); err != nil {
+		return fmt.Errorf("KRİTİK HATA! Kurtarma sonrası servis sağlıksız ve dönülecek model yok: %w", err)
+	}
+	return p.startSoak(entry)
+}
@@ -1,19 +1,35 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 )
 
 const (
 	// Test için ayarları projenin kök dizininden okuyacağız.
-	configPath   = "config.json"
-	pollInterval = 60 * time.Second
+	configPath     = "config.json"
+	activeLinkName = "active_model_link" // Veri kök dizinindeki aktif model bağının varsayılan adı
+	pollInterval   = 60 * time.Second    // Varsayılan kontrol aralığı
 )
 
 func main() {
+	// Yayın tarafı için yardımcı komut: iki model sürümü arasında delta üretir.
+	if len(os.Args) > 1 && os.Args[1] == "make-delta" {
+		if err := runMakeDelta(os.Args[2:]); err != nil {
+			log.Fatalf("Delta üretilemedi: %v", err)
+		}
+		return
+	}
+
 	log.Println("--- EdgeSync Agent Başlatılıyor ---")
 
 	// 1. Yapılandırmayı Yükle
@@ -23,39 +39,262 @@ func main() {
 	}
 	log.Printf("Yapılandırma yüklendi: %+v", *cfg)
 
-	// 2. Gerçek Bileşenleri Oluştur
-	s3Client, err := NewRealS3Client()
-	if err != nil {
-		log.Fatalf("S3 istemcisi oluşturulamadı: %v", err)
+	// 2. Her Model (Hedef) için bir Poller Oluştur
+	// 'targets' boşsa üst düzey ayarlarla tek bir hedef vardır.
+	var pollers []*Poller
+	for _, target := range cfg.TargetConfigs() {
+		poller, err := newTargetPoller(target)
+		if err != nil {
+			log.Fatalf("Hedef '%s' başlatılamadı: %v", target.Name, err)
+		}
+		pollers = append(pollers, poller)
 	}
 
-	deployer := &RealDeployer{}
-	linker := &RealLinker{}
-
-	// 3. Poller'ı Oluştur
-	// Poller, tüm bağımlılıkları (config, s3, deployer, linker) alarak oluşturulur.
-	poller := NewPoller(cfg, s3Client, deployer, linker, "active_model_link")
+	// 3. Arka Plan Poller'larını Başlat
+	// Her hedef kendi aralığıyla, diğerlerinden bağımsız olarak kontrol edilir.
+	for _, poller := range pollers {
+		go func() {
+			poller.log.Printf("Poller başarıyla oluşturuldu. Kontrol aralığı: %v", poller.cfg.PollInterval())
+			for {
+				poller.log.Println("[Poller Worker] Yeni model kontrol ediliyor...")
+				if err := poller.RunOnce(); err != nil {
+					poller.log.Printf("[Poller Worker] Hata: %v", err)
+				}
+				// İzleme sürerken aralık kısalabilir; her turda yeniden hesaplanır.
+				poller.Wait(poller.NextPollInterval())
+			}
+		}()
+	}
 
-	log.Printf("Poller başarıyla oluşturuldu. İlk kontrol %v sonra başlayacak.", pollInterval)
+	// 4. S3 Bildirimlerini Dinle
+	// Aynı kuyruğu kullanan hedefler tek bir dinleyiciyi paylaşır; SQS bir mesajı
+	// sadece bir alıcıya verdiği için her kuyruk bir kez okunmalıdır.
+	queues := make(map[string][]*Poller)
+	for _, poller := range pollers {
+		if poller.cfg.Notifications.Enabled() {
+			queueURL := poller.cfg.Notifications.SQSQueueURL
+			queues[queueURL] = append(queues[queueURL], poller)
+		}
+	}
+	for queueURL, group := range queues {
+		notifier, err := NewRealSQSNotifier(group[0].cfg)
+		if err != nil {
+			log.Fatalf("Bildirim kuyruğu '%s' için istemci oluşturulamadı: %v", queueURL, err)
+		}
+		log.Printf("S3 bildirimleri '%s' kuyruğundan dinleniyor (%d hedef).", queueURL, len(group))
+		go runNotifications(context.Background(), notifier, group)
+	}
 
-	// 4. Arka Plan Poller'ını Başlat
-	go func() {
-		for {
-			log.Println("[Poller Worker] Yeni model kontrol ediliyor...")
-			if err := poller.RunOnce(); err != nil {
-				log.Printf("[Poller Worker] Hata: %v", err)
-			}
-			time.Sleep(pollInterval)
+	// 5. Dosya Kaynaklarını İzle
+	// Linux'ta inotify ile değişiklik anında algılanır; izleme olmayan
+	// platformlarda ve ağ paylaşımlarında düzenli kontrol yedek olarak çalışır.
+	for _, poller := range pollers {
+		if poller.cfg.Source == SourceFile {
+			go runFileWatch(context.Background(), poller)
 		}
-	}()
+	}
 
-	// 5. HTTP Sunucusunu Başlat (Durum ve Sağlık Kontrolü için)
+	// 6. HTTP Sunucusunu Başlat (Durum ve Sağlık Kontrolü için)
 	// Bu, ana goroutine'in sonlanmasını engeller.
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		currentETag := poller.GetStatus()
-		fmt.Fprintf(w, "<h1>EdgeSync Agent Status</h1><p>Current Active Model ETag: %s</p>", currentETag)
+		fmt.Fprint(w, "<h1>EdgeSync Agent Status</h1>")
+		for _, poller := range pollers {
+			writeStatus(w, poller.Status())
+		}
+	})
+
+	// Operatörün karantinayı temizlemesi için:
+	//   curl -X POST "http://localhost:8080/quarantine/clear?target=<hedef>&version=<VersionID veya ETag>"
+	// 'version' verilmezse hepsi, 'target' verilmezse tüm hedefler temizlenir.
+	// Eski 'etag' parametresi de kabul edilir.
+	http.HandleFunc("/quarantine/clear", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "sadece POST desteklenir", http.StatusMethodNotAllowed)
+			return
+		}
+		target := r.URL.Query().Get("target")
+		version := r.URL.Query().Get("version")
+		if version == "" {
+			version = r.URL.Query().Get("etag")
+		}
+		found := false
+		for _, poller := range pollers {
+			if target != "" && poller.cfg.Name != target {
+				continue
+			}
+			found = true
+			if err := poller.ClearQuarantine(version); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("hedef bulunamadı: '%s'", target), http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, "OK")
+	})
+
+	// Saklama politikasını hemen uygulamak için:
+	//   curl -X POST "http://localhost:8080/gc?target=<hedef>"
+	// 'target' verilmezse tüm hedefler temizlenir. Bir dağıtım sürüyorsa 409 döner.
+	http.HandleFunc("/gc", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "sadece POST desteklenir", http.StatusMethodNotAllowed)
+			return
+		}
+		target := r.URL.Query().Get("target")
+		results := make(map[string]GCResult)
+		for _, poller := range pollers {
+			if target != "" && poller.cfg.Name != target {
+				continue
+			}
+			result, err := poller.CollectGarbage()
+			if errors.Is(err, ErrGCBusy) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			name := poller.cfg.Name
+			if name == "" {
+				name = defaultRefName
+			}
+			results[name] = result
+		}
+		if len(results) == 0 {
+			http.Error(w, fmt.Sprintf("hedef bulunamadı: '%s'", target), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(results)
+	})
+
+	// İçerik deposundaki sürüm kayıtları (hedef adına göre) JSON olarak döndürülür:
+	//   curl "http://localhost:8080/store"
+	http.HandleFunc("/store", func(w http.ResponseWriter, r *http.Request) {
+		refs := make(map[string][]VersionRef)
+		for _, poller := range pollers {
+			if poller.cas == nil {
+				continue
+			}
+			targetRefs, err := poller.StoreRefs()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			name := poller.cfg.Name
+			if name == "" {
+				name = defaultRefName
+			}
+			refs[name] = targetRefs
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(refs)
 	})
 
 	log.Println("Web sunucusu http://localhost:8080 adresinde başlatılıyor...")
 	log.Fatal(http.ListenAndServe("localhost:8080", nil))
 }
+
+// newTargetPoller, tek bir hedef için gerçek bileşenleri oluşturur, Poller'ı
+// kurar ve yarım kalmış bir dağıtım varsa onu kurtarır.
+// Birden fazla hedef varsa her hedefin staging ve durum dizinleri kendi adıyla ayrılır.
+func newTargetPoller(cfg *Config) (*Poller, error) {
+	// Veri Dizinlerini Hazırla
+	// Önceki çalışmadan kalan yarım indirmeleri temizle.
+	dataDir := cfg.DataRoot()
+	stagingDir := filepath.Join(dataDir, stagingSubdir, cfg.Name)
+	if err := CleanStaging(stagingDir); err != nil {
+		log.Printf("UYARI: Staging dizini temizlenemedi: %v", err)
+	}
+
+	// Gerçek Bileşenleri Oluştur
+	s3Client, err := newSource(cfg, stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("model kaynağı oluşturulamadı: %w", err)
+	}
+
+	deployer := &RealDeployer{}
+	linker := &RealLinker{}
+	store := NewRealStateStore(filepath.Join(dataDir, stateSubdir, cfg.Name))
+	prober := &RealHealthProber{}
+
+	// Poller, tüm bağımlılıkları (config, s3, deployer, linker, state, sağlık kontrolü)
+	// alarak oluşturulur. Kayıtlı bir durum varsa NewPoller onu yükler.
+	// Modeller, aktif bağ nerede olursa olsun '<data_dir>/models/<hedef>/' altına indirilir.
+	poller := NewPoller(cfg, s3Client, deployer, linker, store, prober, cfg.ActiveLinkPath())
+
+	// Önceki çalışma bir dağıtımın ortasında kesildiyse, poll döngüsü başlamadan
+	// önce dağıtımı tamamla veya geri al.
+	if err := poller.Recover(); err != nil {
+		poller.log.Printf("Yarım kalmış dağıtım kurtarılamadı: %v", err)
+	}
+	return poller, nil
+}
+
+// newSource, hedefin 'source' ayarına göre model kaynağını (S3Client) oluşturur.
+func newSource(cfg *Config, stagingDir string) (S3Client, error) {
+	// Hata durumunda arayüze nil olmayan bir (nil işaretçili) değer dönmemesi için
+	// her istemci ayrı kontrol edilir.
+	switch cfg.Source {
+	case "", SourceS3:
+		client, err := NewRealS3Client(cfg, stagingDir)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case SourceHTTP:
+		client, err := NewRealHTTPClient(cfg, stagingDir)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case SourceFile:
+		client, err := NewRealFileClient(cfg, stagingDir)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case SourceAzure:
+		client, err := NewRealAzureClient(cfg, stagingDir)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("bilinmeyen model kaynağı: '%s'", cfg.Source)
+	}
+}
+
+// writeStatus, bir hedefin durumunu durum paneline yazar.
+func writeStatus(w io.Writer, status PollerStatus) {
+	if status.Name != "" {
+		fmt.Fprintf(w, "<h2>%s</h2>", html.EscapeString(status.Name))
+	}
+	fmt.Fprintf(w, "<p>Current Active Model ETag: %s</p>", html.EscapeString(status.ETag))
+	if status.Key != "" {
+		fmt.Fprintf(w, "<p>Key: %s</p>", html.EscapeString(status.Key))
+	}
+	if status.VersionID != "" {
+		fmt.Fprintf(w, "<p>Version ID: %s</p>", html.EscapeString(status.VersionID))
+	}
+	fmt.Fprintf(w, "<p>State: %s</p>", status.State)
+	if status.State == "soaking" {
+		fmt.Fprintf(w, "<p>Soaking Model Version: %s (%v remaining)</p>", html.EscapeString(status.SoakVersion), status.SoakRemaining)
+	}
+	if status.LastError != "" {
+		fmt.Fprintf(w, "<p>Last Error: %s</p>", html.EscapeString(status.LastError))
+	}
+	if status.Disk.Insufficient {
+		fmt.Fprintf(w, "<p>Insufficient Disk Space: model needs %d bytes, %d bytes free</p>", status.Disk.NeededBytes, status.Disk.FreeBytes)
+	} else if status.Disk.FreeBytes > 0 {
+		fmt.Fprintf(w, "<p>Disk Free: %d bytes</p>", status.Disk.FreeBytes)
+	}
+	for version, q := range status.Quarantine {
+		fmt.Fprintf(w, "<p>Quarantined Version: %s (%d failures, last: %s) - %s</p>",
+			html.EscapeString(version), q.Failures, q.LastFailedAt.Format(time.RFC3339), html.EscapeString(q.Reason))
+	}
+}
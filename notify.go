@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Bildirimler için varsayılan değerler.
+const (
+	defaultNotifyWait     = 20               // SQS uzun yoklama süresi (saniye, en fazla 20)
+	defaultFallbackPoll   = 15 * time.Minute // Bildirimler açıkken yedek kontrol aralığı
+	notifyRetryDelay      = 10 * time.Second // Kuyruk okunamadığında tekrar denemeden önce bekleme
+	s3ObjectCreatedPrefix = "ObjectCreated:"
+)
+
+// NotificationConfig, S3 olay bildirimlerinin okunacağı SQS kuyruğunu belirler.
+// S3 bucket'ı 'ObjectCreated' olaylarını bu kuyruğa (doğrudan veya SNS üzerinden)
+// göndermelidir. SQS bir mesajı tek bir alıcıya verdiği için her cihazın kendi
+// kuyruğu olmalıdır (SNS -> SQS dağıtımı).
+type NotificationConfig struct {
+	SQSQueueURL         string `json:"sqs_queue_url"`         // Kuyruk adresi; boşsa bildirimler kapalıdır
+	SQSEndpoint         string `json:"sqs_endpoint"`          // SQS uyumlu özel uç nokta (örn: ElasticMQ "http://localhost:9324")
+	WaitSeconds         int    `json:"wait_seconds"`          // Uzun yoklama süresi (varsayılan ve en fazla: 20)
+	FallbackPollSeconds int    `json:"fallback_poll_seconds"` // Kaçırılan olaylar için yedek kontrol aralığı (varsayılan: 900)
+}
+
+// Enabled, bildirimlerin açık olup olmadığını döndürür.
+func (n NotificationConfig) Enabled() bool {
+	return n.SQSQueueURL != ""
+}
+
+// S3Event, bir S3 olay bildirimindeki tek bir kayıttır.
+type S3Event struct {
+	EventName string // Örn: "ObjectCreated:Put"
+	Bucket    string
+	Key       string // URL kodlaması çözülmüş anahtar
+	ETag      string
+	VersionID string
+}
+
+// NotificationSource, S3 olaylarının okunduğu kaynağı (örn: SQS kuyruğu) tanımlar.
+type NotificationSource interface {
+	// Receive, yeni olaylar gelene veya uzun yoklama süresi dolana kadar bekler
+	// ve gelen olayları döndürür. Olay yoksa boş liste döner.
+	Receive(ctx context.Context) ([]S3Event, error)
+}
+
+// RealSQSNotifier, NotificationSource arayüzünün S3 olaylarını bir SQS
+// kuyruğundan uzun yoklamayla (long polling) okuyan implementasyonudur.
+type RealSQSNotifier struct {
+	client   *sqs.Client
+	queueURL string
+	wait     int32
+}
+
+// NewRealSQSNotifier, 'cfg.Notifications' içindeki kuyruk için yeni bir
+// RealSQSNotifier oluşturur. Bölge ve CA ayarları S3 istemcisiyle aynıdır.
+func NewRealSQSNotifier(cfg *Config) (*RealSQSNotifier, error) {
+	awsCfg, err := loadAWSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if cfg.Notifications.SQSEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Notifications.SQSEndpoint)
+		}
+	})
+
+	wait := cfg.Notifications.WaitSeconds
+	if wait <= 0 || wait > defaultNotifyWait {
+		wait = defaultNotifyWait
+	}
+	return &RealSQSNotifier{
+		client:   client,
+		queueURL: cfg.Notifications.SQSQueueURL,
+		wait:     int32(wait),
+	}, nil
+}
+
+func (n *RealSQSNotifier) Receive(ctx context.Context) ([]S3Event, error) {
+	output, err := n.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(n.queueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     n.wait,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []S3Event
+	for _, msg := range output.Messages {
+		parsed, err := parseS3Events(aws.ToString(msg.Body))
+		if err != nil {
+			log.Printf("[Notify] UYARI: Anlaşılamayan mesaj atlanıyor (%s): %v", aws.ToString(msg.MessageId), err)
+		}
+		events = append(events, parsed...)
+
+		// Mesaj işlendi (veya anlaşılamadı); kuyrukta bırakmak sadece tekrar
+		// okunmasına yol açar. Kaçırılan bir olay yedek kontrolle yakalanır.
+		if _, err := n.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(n.queueURL),
+			ReceiptHandle: msg.ReceiptHandle,
+		}); err != nil {
+			log.Printf("[Notify] UYARI: Mesaj kuyruktan silinemedi: %v", err)
+		}
+	}
+	return events, nil
+}
+
+// s3Notification, S3'ün gönderdiği olay bildiriminin JSON yapısıdır.
+// SNS üzerinden gelen bildirimlerde asıl olay 'Message' alanında metin olarak bulunur.
+type s3Notification struct {
+	Type    string `json:"Type"`    // SNS sarmalı ise "Notification"
+	Message string `json:"Message"` // SNS sarmalındaki asıl bildirim
+	Event   string `json:"Event"`   // Bildirim ilk kurulduğunda gönderilen "s3:TestEvent"
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key       string `json:"key"`
+				ETag      string `json:"eTag"`
+				VersionID string `json:"versionId"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// parseS3Events, bir SQS mesaj gövdesindeki S3 olaylarını çözer. Doğrudan S3
+// bildirimleri ve SNS ile sarmalanmış bildirimler desteklenir; test olayları
+// boş liste döndürür.
+func parseS3Events(body string) ([]S3Event, error) {
+	var n s3Notification
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		return nil, fmt.Errorf("bildirim JSON'u çözülemedi: %w", err)
+	}
+	if n.Type == "Notification" && n.Message != "" {
+		return parseS3Events(n.Message)
+	}
+
+	var events []S3Event
+	for _, r := range n.Records {
+		// S3, anahtarları form kodlamasıyla gönderir (boşluk -> '+').
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return events, fmt.Errorf("anahtar çözülemedi (%s): %w", r.S3.Object.Key, err)
+		}
+		events = append(events, S3Event{
+			EventName: r.EventName,
+			Bucket:    r.S3.Bucket.Name,
+			Key:       key,
+			ETag:      r.S3.Object.ETag,
+			VersionID: r.S3.Object.VersionID,
+		})
+	}
+	return events, nil
+}
+
+// MatchesEvent, bir S3 olayının bu hedefin izlediği modeli (tek anahtar veya
+// prefix) değiştirip değiştirmediğini döndürür.
+func (p *Poller) MatchesEvent(ev S3Event) bool {
+	if !strings.HasPrefix(ev.EventName, s3ObjectCreatedPrefix) || ev.Bucket != p.cfg.S3Bucket {
+		return false
+	}
+	if p.cfg.S3Prefix != "" {
+		return strings.HasPrefix(ev.Key, p.cfg.S3Prefix)
+	}
+	return ev.Key == p.cfg.S3Key
+}
+
+// Trigger, bir sonraki kontrolün beklemeden yapılmasını ister. Zaten bekleyen
+// bir istek varsa yenisi eklenmez.
+func (p *Poller) Trigger() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wait, 'interval' süresi dolana veya Trigger çağrılana kadar bekler.
+func (p *Poller) Wait(interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-p.wake:
+	}
+}
+
+// runNotifications, 'source' kaynağını 'ctx' iptal edilene kadar dinler ve
+// gelen her olayı, o olayın ilgilendirdiği hedeflerin hemen kontrol edilmesini
+// isteyerek dağıtır.
+func runNotifications(ctx context.Context, source NotificationSource, pollers []*Poller) {
+	for ctx.Err() == nil {
+		events, err := source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[Notify] UYARI: Bildirim kuyruğu okunamadı, %v sonra tekrar denenecek: %v", notifyRetryDelay, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(notifyRetryDelay):
+			}
+			continue
+		}
+		for _, ev := range events {
+			for _, poller := range pollers {
+				if poller.MatchesEvent(ev) {
+					poller.log.Printf("[Notify] '%s' olayı alındı (anahtar: '%s', ETag: '%s'). Model hemen kontrol edilecek.", ev.EventName, ev.Key, ev.ETag)
+					poller.Trigger()
+				}
+			}
+		}
+	}
+}
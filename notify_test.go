@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// MockNotificationSource, NotificationSource arayüzünü taklit eder.
+// Olayları bir kez döndürür, sonra bağlam iptal edilene kadar bekler.
+type MockNotificationSource struct {
+	Events []S3Event
+}
+
+func (m *MockNotificationSource) Receive(ctx context.Context) ([]S3Event, error) {
+	if events := m.Events; events != nil {
+		m.Events = nil
+		return events, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+const s3EventBody = `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"models"},"object":{"key":"prod/my+model.bin","eTag":"abc","versionId":"v2"}}}]}`
+
+func TestParseS3Events(t *testing.T) {
+	snsBody, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": s3EventBody})
+
+	for name, body := range map[string]string{"doğrudan": s3EventBody, "sns": string(snsBody)} {
+		events, err := parseS3Events(body)
+		if err != nil {
+			t.Fatalf("%s: parseS3Events() hata döndürdü: %v", name, err)
+		}
+		want := S3Event{EventName: "ObjectCreated:Put", Bucket: "models", Key: "prod/my model.bin", ETag: "abc", VersionID: "v2"}
+		if len(events) != 1 || events[0] != want {
+			t.Errorf("%s: beklenen %+v, alınan %+v", name, want, events)
+		}
+	}
+
+	// Bildirim kurulurken gönderilen test olayı yok sayılmalı.
+	events, err := parseS3Events(`{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"models"}`)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Test olayı boş liste döndürmeliydi, alınan: %+v, %v", events, err)
+	}
+	if _, err := parseS3Events("json değil"); err == nil {
+		t.Error("Bozuk mesaj hata döndürmeliydi")
+	}
+}
+
+func TestPoller_MatchesEvent(t *testing.T) {
+	single := &Poller{cfg: &Config{S3Bucket: "models", S3Key: "prod/model.bin"}}
+	prefix := &Poller{cfg: &Config{S3Bucket: "models", S3Prefix: "prod/releases/"}}
+
+	tests := []struct {
+		poller *Poller
+		event  S3Event
+		want   bool
+	}{
+		{single, S3Event{EventName: "ObjectCreated:Put", Bucket: "models", Key: "prod/model.bin"}, true},
+		{single, S3Event{EventName: "ObjectCreated:CompleteMultipartUpload", Bucket: "models", Key: "prod/model.bin"}, true},
+		{single, S3Event{EventName: "ObjectRemoved:Delete", Bucket: "models", Key: "prod/model.bin"}, false},
+		{single, S3Event{EventName: "ObjectCreated:Put", Bucket: "other", Key: "prod/model.bin"}, false},
+		{single, S3Event{EventName: "ObjectCreated:Put", Bucket: "models", Key: "prod/model.bin.sig"}, false},
+		{prefix, S3Event{EventName: "ObjectCreated:Put", Bucket: "models", Key: "prod/releases/model-1.2.0.bin"}, true},
+		{prefix, S3Event{EventName: "ObjectCreated:Put", Bucket: "models", Key: "staging/model.bin"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.poller.MatchesEvent(tt.event); got != tt.want {
+			t.Errorf("MatchesEvent(%+v) = %v, beklenen %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+// TestRunNotifications, eşleşen bir olayın sadece ilgili hedefi beklemeden
+// uyandırdığını test eder.
+func TestRunNotifications(t *testing.T) {
+	newPoller := func(key string) *Poller {
+		cfg := &Config{S3Bucket: "models", S3Key: key, DeployScriptPath: "deploy.sh"}
+		return NewPoller(cfg, &MockS3Client{}, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, filepath.Join(t.TempDir(), "active_model_link"))
+	}
+	matching, other := newPoller("prod/model.bin"), newPoller("prod/vision.bin")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := &MockNotificationSource{Events: []S3Event{{EventName: "ObjectCreated:Put", Bucket: "models", Key: "prod/model.bin"}}}
+	go runNotifications(ctx, source, []*Poller{matching, other})
+
+	done := make(chan struct{})
+	go func() {
+		matching.Wait(time.Minute)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Eşleşen olay hedefi uyandırmalıydı")
+	}
+	select {
+	case <-other.wake:
+		t.Error("İlgisiz hedef uyandırılmamalıydı")
+	default:
+	}
+}
+
+func TestConfig_PollIntervalWithNotifications(t *testing.T) {
+	cfg := &Config{PollIntervalSeconds: 60, Notifications: NotificationConfig{SQSQueueURL: "http://localhost:9324/queue/models"}}
+	if got := cfg.PollInterval(); got != defaultFallbackPoll {
+		t.Errorf("Bildirimler açıkken yedek aralık kullanılmalıydı, alınan: %v", got)
+	}
+	cfg.Notifications.FallbackPollSeconds = 300
+	if got := cfg.PollInterval(); got != 5*time.Minute {
+		t.Errorf("Yapılandırılan yedek aralık kullanılmalıydı, alınan: %v", got)
+	}
+}
+
+// TestPoller_SoakIntervalWithNotifications, bildirimler açıkken de izleme
+// penceresindeki modelin düzenli aralıkla kontrol edildiğini test eder.
+func TestPoller_SoakIntervalWithNotifications(t *testing.T) {
+	cfg := &Config{PollIntervalSeconds: 60, Notifications: NotificationConfig{SQSQueueURL: "http://localhost:9324/queue/models"}}
+	p := NewPoller(cfg, &MockS3Client{}, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, filepath.Join(t.TempDir(), "active_model_link"))
+	if got := p.NextPollInterval(); got != defaultFallbackPoll {
+		t.Errorf("İzleme yokken yedek aralık kullanılmalıydı, alınan: %v", got)
+	}
+	p.soak = &JournalEntry{ETag: "v2", SoakUntil: time.Now().Add(time.Hour)}
+	if got := p.NextPollInterval(); got != time.Minute {
+		t.Errorf("İzleme sırasında düzenli aralık kullanılmalıydı, alınan: %v", got)
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log" // Ekrana/dosyaya log basmak için
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
 // --- ARAYÜZLER (INTERFACES) ---
@@ -12,17 +18,34 @@ import (
 
 // S3Client, S3'ten bilgi almak ve indirmek için gereken fonksiyonları tanımlar.
 type S3Client interface {
-	// HeadObject, bir dosyanın ETag'ini (versiyonunu) döndürür.
-	HeadObject(bucket, key string) (string, error)
-	// DownloadObject, bir dosyayı S3'ten indirir.
-	DownloadObject(bucket, key, destinationPath string) error
+	// HeadObject, bir dosyanın revizyon bilgilerini (VersionID, ETag, boyut,
+	// metadata...) döndürür. 'versionID' boşsa en güncel revizyon döner.
+	HeadObject(bucket, key, versionID string) (ObjectVersion, error)
+	// ExpectedSHA256, revizyon için yayınlanmış SHA-256 özetini (hex) döndürür.
+	// Yayınlanmış bir özet yoksa boş string döner.
+	ExpectedSHA256(bucket string, obj ObjectVersion) (string, error)
+	// DownloadObject, belirtilen revizyonu indirir ve indirilen verinin SHA-256
+	// özetini döndürür. 'expectedSHA256' boş değilse ve özet eşleşmezse,
+	// dosya hedefe yazılmaz ve ErrChecksumMismatch döner.
+	DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error)
+	// ListObjects, 'prefix' altındaki tüm nesneleri (Key, ETag, LastModified, Size) listeler.
+	ListObjects(bucket, prefix string) ([]ObjectVersion, error)
+	// FetchObject, küçük bir nesnenin (imza, özet dosyası vb.) içeriğini belleğe okur.
+	// Nesne yoksa ErrObjectNotFound döner.
+	FetchObject(bucket, key string) ([]byte, error)
 }
 
+// ErrObjectNotFound, istenen nesne kaynakta bulunmadığında döndürülür.
+var ErrObjectNotFound = errors.New("nesne bulunamadı")
+
 // Deployer, bir script'i çalıştırmak için gereken fonksiyonu tanımlar.
 type Deployer interface {
 	// Run, belirtilen script'i verilen argümanlarla çalıştırır.
 	// Dönen 'error', script'in 'exit code 0' dışında bir kodla bitmesi durumudur.
 	Run(scriptPath string, args ...string) error
+	// Output, script'i çalıştırır ve standart çıktısını döndürür.
+	// (örn: `deploy.sh --error-rate` ile hata oranını okumak için)
+	Output(scriptPath string, args ...string) (string, error)
 }
 
 // Linker, sembolik bağ (symlink) yönetimi için gereken fonksiyonu tanımlar.
@@ -33,6 +56,27 @@ type Linker interface {
 	Get(linkName string) (string, error)
 }
 
+// HealthProber, reload sonrası servisin sağlığını doğrulamak için gereken fonksiyonu tanımlar.
+type HealthProber interface {
+	// Probe, tek bir sağlık kontrolünü çalıştırır. Kontrol başarısızsa hata döner.
+	Probe(ctx context.Context, check HealthCheck) error
+}
+
+// StateStore, ajanın durumunu (state) ve dağıtım günlüğünü (journal)
+// yeniden başlatmalar arasında kalıcı olarak saklamak için gereken fonksiyonları tanımlar.
+type StateStore interface {
+	// Load, en son kaydedilen durumu okur. Henüz kayıt yoksa boş bir durum döner.
+	Load() (*AgentState, error)
+	// Save, durumu atomik olarak kaydeder.
+	Save(state *AgentState) error
+	// AppendJournal, dağıtım günlüğüne yeni bir aşama kaydı ekler (write-ahead).
+	AppendJournal(entry JournalEntry) error
+	// LastJournal, günlükteki son kaydı döndürür. Günlük boşsa nil döner.
+	LastJournal() (*JournalEntry, error)
+	// ClearJournal, günlüğü temizler (dağıtım tamamlandı veya geri alındı).
+	ClearJournal() error
+}
+
 // --- ÇEKİRDEK YAPI (POLLER STRUCT) ---
 
 // Poller, ajanımızın tüm durumunu (state) ve bağımlılıklarını (dependencies) tutar.
@@ -41,138 +85,524 @@ type Poller struct {
 	s3     S3Client
 	deploy Deployer
 	linker Linker
+	store  StateStore
+	prober HealthProber
 
 	// Yapılandırma (Config'den gelen)
 	cfg *Config
+	// log, hedefin adıyla (birden fazla model yönetiliyorsa) ön eklenmiş logger'dır.
+	log *log.Logger
+
+	// runMu, kontrol döngüsü (RunOnce) ile isteğe bağlı temizliğin (GC) aynı anda
+	// çalışmasını önler; böylece indirilmekte olan bir model silinemez.
+	runMu sync.Mutex
+	// wake, bir S3 bildirimi geldiğinde kontrol döngüsünü beklemeden uyandırır.
+	wake chan struct{}
 
 	// Durum (State)
 	// mu, lastKnownETag gibi state alanlarına eşzamanlı erişimi korur.
-	mu              sync.RWMutex
-	lastKnownETag   string // En son başarıyla deploy edilen modelin ETag'i
-	activeModelPath string // Sembolik bağın (link) adı
+	mu                    sync.RWMutex
+	lastKnownETag         string                     // En son başarıyla deploy edilen modelin ETag'i
+	lastKnownVersionID    string                     // ...ve S3 VersionID'si (versiyonlama kapalıysa boş)
+	activeModelPath       string                     // Sembolik bağın (link) adı
+	deployedKey           string                     // Aktif modelin S3 anahtarı (prefix modunda değişir)
+	deployedModel         string                     // Sembolik bağın gösterdiği model dosyası
+	previousModel         string                     // Rollback adayı: bir önceki stabil model
+	deployedSHA256        string                     // Aktif modelin SHA-256 özeti
+	deployedSignedVersion uint64                     // Aktif modelin imzalı sürüm numarası
+	deployedAt            time.Time                  // Son başarılı dağıtımın zamanı
+	lastError             string                     // Son döngüde alınan hata
+	disk                  DiskStatus                 // Son disk alanı kontrolünün sonucu
+	cas                   *ContentStore              // İçerik adresli model deposu (kapalıysa nil)
+	soak                  *JournalEntry              // İzleme penceresindeki dağıtım (yoksa nil)
+	soakFailure           error                      // İzlemede bozulan ama henüz geri alınamayan modelin hatası
+	quarantined           map[string]QuarantineEntry // Başarısız olmuş revizyonlar (VersionID veya ETag)
+	lastRemote            ObjectVersion              // Son döngüde görülen uzak revizyon (sadece runMu altında kullanılır)
 }
 
 // NewPoller, yeni bir Poller struct'ı oluşturmak için "constructor" fonksiyonudur.
-func NewPoller(cfg *Config, s3 S3Client, deploy Deployer, linker Linker, activePath string) *Poller {
-	return &Poller{
+// Kalıcı durum (state) varsa buradan yüklenir; böylece ajan yeniden başladığında
+// hangi modelin deploy edildiğini unutmaz.
+func NewPoller(cfg *Config, s3 S3Client, deploy Deployer, linker Linker, store StateStore, prober HealthProber, activePath string) *Poller {
+	p := &Poller{
 		s3:              s3,
 		deploy:          deploy,
 		linker:          linker,
+		store:           store,
+		prober:          prober,
 		cfg:             cfg,
+		log:             log.Default(),
 		activeModelPath: activePath,
-		// lastKnownETag başlangıçta boştur, ilk çalışmada set edilecek.
+		quarantined:     make(map[string]QuarantineEntry),
+		wake:            make(chan struct{}, 1),
+	}
+	if cfg.ContentStore {
+		// Depo, modellerle aynı dosya sisteminde (veri kök dizininde) olmalıdır
+		// (sabit bağlantılar için); aktif bağ başka bir dizinde olabilir.
+		p.cas = NewContentStore(filepath.Join(cfg.DataRoot(), storeSubdir))
 	}
+	if cfg.Name != "" {
+		p.log = log.New(log.Writer(), fmt.Sprintf("[%s] ", cfg.Name), log.Flags()|log.Lmsgprefix)
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		// Durum okunamazsa ajanı durdurmuyoruz; ilk çalışma gibi davranılır.
+		p.log.Printf("[Poller] UYARI: Kayıtlı durum yüklenemedi, sıfırdan başlanıyor: %v", err)
+		return p
+	}
+	p.lastKnownETag = state.ETag
+	p.lastKnownVersionID = state.VersionID
+	p.deployedKey = state.Key
+	p.deployedModel = state.ActiveModelPath
+	p.previousModel = state.PreviousModel
+	p.deployedSHA256 = state.SHA256
+	p.deployedSignedVersion = state.SignedVersion
+	p.deployedAt = state.DeployedAt
+	p.lastError = state.LastError
+	if state.Quarantine != nil {
+		p.quarantined = state.Quarantine
+	}
+	if p.lastKnownETag != "" {
+		p.log.Printf("[Poller] Kayıtlı durum yüklendi. Aktif ETag: '%s', VersionID: '%s' (%s)", p.lastKnownETag, p.lastKnownVersionID, p.deployedModel)
+	}
+	return p
 }
 
 // RunOnce, Poller'ın bir kontrol döngüsünü çalıştırır (Akış B).
-func (p *Poller) RunOnce() error {
-	log.Println("[Poller] Yeni model versiyonu kontrol ediliyor...")
+// Döngü hata ile biterse, hata kalıcı duruma 'son hata' olarak kaydedilir.
+func (p *Poller) RunOnce() (err error) {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	defer func() {
+		if err != nil {
+			p.recordError(err)
+		}
+	}()
+
+	// İzleme penceresinde bir model varsa, pencere bitene kadar yeni model aranmaz.
+	p.mu.RLock()
+	soaking := p.soak != nil
+	p.mu.RUnlock()
+	if soaking {
+		return p.checkSoak()
+	}
+
+	p.log.Println("[Poller] Yeni model versiyonu kontrol ediliyor...")
 
 	// 1. ADIM: S3'ü Kontrol Et (FG3)
-	remoteETag, err := p.s3.HeadObject(p.cfg.S3Bucket, p.cfg.S3Key)
-	if err != nil {
+	// Koşullu GET açıksa kontrol ve indirme tek istektir; model değiştiyse
+	// gövde 'opened' içinde açık olarak bekler.
+	var (
+		remote ObjectVersion
+		opened OpenObject
+	)
+	if fetcher, ok := p.conditionalGetEnabled(); ok {
+		remote, opened, err = p.resolveRemoteConditional(fetcher)
+		if err != nil {
+			return err
+		}
+		if opened != nil {
+			defer opened.Close()
+		}
+	} else if remote, err = p.resolveRemote(); err != nil {
 		return fmt.Errorf("S3 HeadObject hatası: %w", err)
 	}
+	remoteID := remote.ID()
+	p.lastRemote = remote
 
-	// 2. ADIM: ETag'leri Karşılaştır
+	// 2. ADIM: Revizyonları Karşılaştır
 	if p.lastKnownETag == "" {
-		// Bu, ajanın ilk çalışması. Mevcut ETag'i "bilinen" olarak kaydet.
-		log.Printf("[Poller] İlk çalışma. Mevcut ETag '%s' olarak ayarlandı.", remoteETag)
+		// Bu, ajanın ilk çalışması. Mevcut revizyonu "bilinen" olarak kaydet.
+		p.log.Printf("[Poller] İlk çalışma. Mevcut revizyon '%s' (ETag: '%s') olarak ayarlandı.", remoteID, remote.ETag)
+		// Mevcut sembolik bağın hedefini al (eğer varsa) ve onu aktif model olarak sakla.
+		// İlk çalışmada deploy yapılmaz, sadece durum öğrenilir ve kaydedilir.
+		currentTarget, _ := p.linker.Get(p.activeModelPath)
 		p.mu.Lock()
-		p.lastKnownETag = remoteETag
+		p.lastKnownETag = remote.ETag
+		p.lastKnownVersionID = remote.VersionID
+		p.deployedKey = remote.Key
+		p.deployedModel = currentTarget
 		p.mu.Unlock()
-		// Ve mevcut sembolik bağın hedefini al (eğer varsa)
-		// p.activeModelPath'in nereyi gösterdiğini öğrenip onu "eski" model olarak saklayabiliriz.
-		// Şimdilik basitleştirelim: ilk çalışmada deploy yapma, sadece durumu öğren.
-		return nil
+		return p.saveState()
 	}
 
-	if remoteETag == p.lastKnownETag {
+	if p.isCurrent(remote) {
 		// Değişiklik yok.
-		log.Println("[Poller] Model değişmemiş. (ETag:", remoteETag, ")")
+		p.log.Println("[Poller] Model değişmemiş. (Revizyon:", remoteID, ")")
+		return nil
+	}
+
+	if p.isQuarantined(remoteID) {
+		// Bu versiyon daha önce başarısız oldu; tekrar indirip denemenin anlamı yok.
+		p.log.Printf("[Poller] Revizyon '%s' karantinada, atlanıyor. (Yeni bir versiyon veya operatör onayı bekleniyor)", remoteID)
 		return nil
 	}
 
 	// 3. ADIM: YENİ MODEL VAR! (FG4)
-	log.Printf("[Poller] YENİ MODEL ALGILANDI! Eski: '%s', Yeni: '%s'", p.lastKnownETag, remoteETag)
+	p.log.Printf("[Poller] YENİ MODEL ALGILANDI! Eski: '%s', Yeni: '%s' (ETag: '%s', Anahtar: '%s')", p.currentVersion(), remoteID, remote.ETag, remote.Key)
 
 	// Eski (mevcut) çalışan modeli bul (Rollback için lazım)
 	// Tasarımda `active_model` adını /var/lib/edgesync/active_model olarak belirlemiştik
 	oldModelTarget, err := p.linker.Get(p.activeModelPath)
 	if err != nil {
-		log.Printf("[Poller] UYARI: Rollback için eski modelin yolu okunamadı: %v", err)
+		p.log.Printf("[Poller] UYARI: Rollback için eski modelin yolu okunamadı: %v", err)
 		// oldModelTarget="" olarak devam et, bu durumda rollback yapılamaz.
 	}
 
-	// Yeni modeli indirilecek geçici bir yer belirle
-	// Tasarım: /var/lib/edgesync/staging/model-[ETag].bin
-	// Şimdilik test için basit bir yol kullanalım (MockLinker bunu önemsemeyecek)
-	newModelDownloadPath := fmt.Sprintf("models/model-%s.bin", remoteETag)
+	// Yeni modelin indirileceği yeri belirle.
+	// Tasarım: modeller, veri kök dizinindeki ('data_dir') 'models/' altında durur; aktif bağ
+	// başka bir dizinde olsa bile staging ile aynı dosya sisteminde kalırlar.
+	// Birden fazla hedef varsa her hedefin modelleri 'models/<hedef adı>/' altındadır.
+	// (örn: /var/lib/edgesync/active_model -> /var/lib/edgesync/models/model-[VersionID veya ETag].bin)
+	// Dosya adı revizyona göre verildiği için hangi revizyonun deploy edildiği her zaman bellidir.
+	newModelDownloadPath := filepath.Join(p.modelsDir(), fmt.Sprintf("model-%s.bin", fileSafe(remoteID)))
+	downloadPath := newModelDownloadPath
+	if p.cfg.Bundle {
+		// Paket modunda izlenen nesne manifestodur; dosyalar 'bundle-<revizyon>/'
+		// dizininde toplanır ve aktif bağ bu dizini gösterir.
+		newModelDownloadPath = filepath.Join(filepath.Dir(newModelDownloadPath), fmt.Sprintf("bundle-%s", fileSafe(remoteID)))
+		downloadPath = bundleDownloadPath(newModelDownloadPath)
+	}
+
+	// Dağıtım günlüğü (journal) kaydı. Her aşama tamamlandığında diske yazılır,
+	// böylece süreç yarıda kesilirse Recover() nerede kalındığını bilir.
+	entry := JournalEntry{
+		Key:            remote.Key,
+		ETag:           remote.ETag,
+		VersionID:      remote.VersionID,
+		ModelPath:      newModelDownloadPath,
+		PreviousTarget: oldModelTarget,
+	}
 
-	err = p.s3.DownloadObject(p.cfg.S3Bucket, p.cfg.S3Key, newModelDownloadPath)
+	// İndirilen baytların S3'teki baytlarla aynı olduğunu doğrulamak için
+	// yayınlanmış SHA-256 özetini bul. (ETag, multipart yüklemelerde içerik özeti değildir.)
+	expectedSHA256, err := p.s3.ExpectedSHA256(p.cfg.S3Bucket, remote)
 	if err != nil {
-		return fmt.Errorf("S3 DownloadObject hatası: %w", err)
+		return fmt.Errorf("beklenen SHA-256 özeti okunamadı: %w", err)
+	}
+	if expectedSHA256 == "" {
+		if p.cfg.RequireSHA256 {
+			return fmt.Errorf("revizyon '%s' için yayınlanmış bir SHA-256 özeti yok (require_sha256 açık)", remoteID)
+		}
+		p.log.Println("[Poller] UYARI: Yayınlanmış bir SHA-256 özeti yok, içerik doğrulanmadan devam ediliyor.")
+	}
+
+	// Modelin diske sığdığından indirmeye başlamadan emin ol. Aynı içerik depoda
+	// zaten varsa yer gerekmez. Paketlerde toplam boyut manifestodan okunur.
+	if !p.cfg.Bundle && !(p.cas != nil && p.cas.Contains(expectedSHA256)) {
+		if err := p.ensureDiskSpace(remote.Size, filepath.Dir(downloadPath)); err != nil {
+			if errors.Is(err, ErrModelTooLarge) {
+				p.quarantine(remoteID, err)
+			}
+			return err
+		}
+	}
+
+	// İmza doğrulaması açıksa imzayı indirmeden önce al; imza yoksa büyük
+	// modeli boşuna indirme.
+	signature, err := p.fetchSignature(remote.Key)
+	if err != nil {
+		return err
+	}
+
+	digest, err := p.downloadModel(remote, opened, oldModelTarget, downloadPath, expectedSHA256)
+	if err != nil {
+		err = fmt.Errorf("S3 DownloadObject hatası: %w", err)
+		if errors.Is(err, ErrChecksumMismatch) {
+			// Bozuk veya kurcalanmış içerik test aşamasına asla geçmez.
+			p.quarantine(remoteID, err)
+		}
+		return err
+	}
+	entry.SHA256 = digest
+	p.log.Printf("[Poller] Yeni model '%s' adresine başarıyla indirildi. (SHA-256: %s)", newModelDownloadPath, digest)
+
+	// İmza, test aşamasından önce doğrulanır. Güvenilmeyen bir model hiçbir
+	// script'e verilmez.
+	if err := p.verifySignature(digest, signature); err != nil {
+		err = fmt.Errorf("model imzası doğrulanamadı (revizyon: '%s'): %w", remoteID, err)
+		p.quarantine(remoteID, err)
+		return err
+	}
+	if signature != nil {
+		entry.SignedVersion = signature.version
+		p.log.Printf("[Poller] Model imzası doğrulandı. (imzalı sürüm: %d)", signature.version)
+	}
+
+	// Sürümün kullandığı dosyalar (içerik deposu açıksa) kaydedilir.
+	refFiles := []RefFile{{Path: filepath.Base(downloadPath), SHA256: digest}}
+
+	// Paket modunda manifesto doğrulandıktan sonra listelenen dosyalar indirilir.
+	// Manifesto her dosyanın özetini içerdiği için manifestonun imzası tüm paketi kapsar.
+	if p.cfg.Bundle {
+		manifest, err := p.assembleBundle(remote.Key, newModelDownloadPath)
+		if err != nil {
+			err = fmt.Errorf("model paketi hazırlanamadı: %w", err)
+			if errors.Is(err, ErrChecksumMismatch) || errors.Is(err, ErrManifestInvalid) || errors.Is(err, ErrModelTooLarge) {
+				p.quarantine(remoteID, err)
+			}
+			return err
+		}
+		for _, f := range manifest.Files {
+			refFiles = append(refFiles, RefFile{Path: f.Path, SHA256: f.SHA256})
+		}
+	} else if p.cfg.Archive.Format != "" {
+		// Arşiv, imzası doğrulandıktan sonra 'model-<revizyon>/' dizinine açılır;
+		// arşiv dosyasının kendisine artık ihtiyaç yoktur.
+		archivePath := newModelDownloadPath
+		newModelDownloadPath = strings.TrimSuffix(archivePath, ".bin")
+		entry.ModelPath = newModelDownloadPath
+		// Açılmış boyut arşivin boyutundan çok büyük olabileceği için her dosya
+		// yazılmadan önce toplam boyut sınırı ve boş alan yeniden denetlenir.
+		var extracted int64
+		reserve := func(size int64) error {
+			extracted += size
+			if max := p.cfg.Disk.MaxModelBytes; max > 0 && extracted > max {
+				return fmt.Errorf("%w: açılmış arşiv en az %d bayt (sınır: %d bayt)", ErrModelTooLarge, extracted, max)
+			}
+			return p.ensureDiskSpace(size, p.modelsDir())
+		}
+		err := extractArchive(archivePath, newModelDownloadPath, p.cfg.Archive, reserve)
+		os.Remove(archivePath)
+		if err != nil {
+			err = fmt.Errorf("model arşivi açılamadı: %w", err)
+			if errors.Is(err, ErrArchiveInvalid) || errors.Is(err, ErrModelTooLarge) {
+				p.quarantine(remoteID, err)
+			}
+			return err
+		}
+		p.log.Printf("[Poller] Model arşivi '%s' dizinine açıldı.", newModelDownloadPath)
+	}
+	p.recordRef(&entry, refFiles)
+	if err := p.writeJournal(&entry, PhaseDownloaded); err != nil {
+		return err
 	}
-	log.Printf("[Poller] Yeni model '%s' adresine başarıyla indirildi.", newModelDownloadPath)
 
 	// 4. ADIM: Test Et (FG5c)
-	log.Println("[Poller] Yeni model test ediliyor... (`deploy.sh --test`)")
+	p.log.Println("[Poller] Yeni model test ediliyor... (`deploy.sh --test`)")
 	err = p.deploy.Run(p.cfg.DeployScriptPath, "--test", newModelDownloadPath)
 	if err != nil {
-		// Test başarısız! Dağıtımı iptal et.
-		return fmt.Errorf("yeni model testi BAŞARISIZ oldu: %w", err)
+		// Test başarısız! Dağıtımı iptal et. Sembolik bağa dokunulmadığı için günlük temizlenir.
+		p.clearJournal()
+		err = fmt.Errorf("yeni model testi BAŞARISIZ oldu: %w", err)
+		p.quarantine(remoteID, err)
+		return err
+	}
+	p.log.Println("[Poller] Yeni model testi BAŞARILI.")
+	if err := p.writeJournal(&entry, PhaseTested); err != nil {
+		return err
 	}
-	log.Println("[Poller] Yeni model testi BAŞARILI.")
 
 	// 5. ADIM: Atomik Değişim (Symlink) (FG5d)
-	log.Printf("[Poller] Sembolik bağ (symlink) '%s' -> '%s' olarak değiştiriliyor...", p.activeModelPath, newModelDownloadPath)
+	p.log.Printf("[Poller] Sembolik bağ (symlink) '%s' -> '%s' olarak değiştiriliyor...", p.activeModelPath, newModelDownloadPath)
 	err = p.linker.Set(newModelDownloadPath, p.activeModelPath)
 	if err != nil {
+		p.clearJournal()
 		return fmt.Errorf("sembolik bağ değiştirilemedi: %w", err)
 	}
+	if err := p.writeJournal(&entry, PhaseLinked); err != nil {
+		// Bağ değişti ama günlüğe yazılamadı; bilinmeyen durumda kalmamak için geri al.
+		if errRollback := p.rollback(oldModelTarget); errRollback != nil {
+			return errRollback
+		}
+		return fmt.Errorf("dağıtım hatası (rollback yapıldı): %w", err)
+	}
 
 	// 6. ADIM: Servisi Yeniden Başlat (FG5e)
-	log.Println("[Poller] Servis yeniden başlatılıyor... (`deploy.sh --reload`)")
+	p.log.Println("[Poller] Servis yeniden başlatılıyor... (`deploy.sh --reload`)")
 	err = p.deploy.Run(p.cfg.DeployScriptPath, "--reload")
 	if err != nil {
 		// YENİDEN BAŞLATMA BAŞARISIZ! OTOMATİK ROLLBACK (FG6.3)
-		log.Printf("[Poller] HATA! Servis yeni modelle başlatılamadı: %v", err)
-		log.Println("[Poller] OTOMATİK ROLLBACK BAŞLATILIYOR...")
-
-		if oldModelTarget == "" {
-			return fmt.Errorf("ROLLBACK BAŞARISIZ: Eski modelin yolu bilinmiyor")
-		}
-
-		// Sembolik bağı acilen ESKİ modele geri çevir.
-		errRollback := p.linker.Set(oldModelTarget, p.activeModelPath)
-		if errRollback != nil {
-			// Bu olursa çok büyük felaket (sistem "down" kalır)
-			return fmt.Errorf("KRİTİK HATA! Rollback sırasında sembolik bağ değiştirilemedi: %w", errRollback)
+		p.log.Printf("[Poller] HATA! Servis yeni modelle başlatılamadı: %v", err)
+		p.quarantine(remoteID, err)
+		if errRollback := p.rollback(oldModelTarget); errRollback != nil {
+			return errRollback
 		}
-
-		// Servisi ESKİ modelle tekrar başlat.
-		errReloadOld := p.deploy.Run(p.cfg.DeployScriptPath, "--reload")
-		if errReloadOld != nil {
-			return fmt.Errorf("KRİTİK HATA! Rollback başarılı ancak servis eski modelle de başlatılamadı: %w", errReloadOld)
-		}
-
-		log.Println("[Poller] ROLLBACK BAŞARILI. Sistem eski stabil modele döndü.")
 		// Orijinal hatayı döndür ki loglarda görünsün.
 		return fmt.Errorf("dağıtım hatası (rollback yapıldı): %w", err)
 	}
+	if err := p.writeJournal(&entry, PhaseReloaded); err != nil {
+		return err
+	}
 
-	// 7. ADIM: BAŞARILI!
-	log.Printf("[Poller] DAĞITIM BAŞARILI. Yeni aktif model ETag: '%s'", remoteETag)
+	// 7. ADIM: Sağlık Kontrolü
+	// Servis yeniden başladı ama hata veriyor olabilir. Kontroller başarısız
+	// olursa reload hatasıyla aynı rollback yolu kullanılır.
+	if err := p.checkHealth(); err != nil {
+		p.log.Printf("[Poller] HATA! Servis yeni modelle sağlıksız: %v", err)
+		p.quarantine(remoteID, err)
+		if errRollback := p.rollback(oldModelTarget); errRollback != nil {
+			return errRollback
+		}
+		return fmt.Errorf("sağlık kontrolü hatası (rollback yapıldı): %w", err)
+	}
+
+	// 8. ADIM: İzleme (Soak)
+	// Model, izleme penceresi sorunsuz biterse commit edilir ve "stabil" sayılır.
+	return p.startSoak(&entry)
+}
+
+// commit, tamamlanan bir dağıtımı kalıcı duruma işler ve günlüğü kapatır.
+func (p *Poller) commit(entry *JournalEntry) error {
+	p.log.Printf("[Poller] DAĞITIM BAŞARILI. Yeni aktif model revizyonu: '%s' (ETag: '%s')", entry.ID(), entry.ETag)
 	p.mu.Lock()
-	p.lastKnownETag = remoteETag // Durumu güncelle.
+	p.lastKnownETag = entry.ETag // Durumu güncelle.
+	p.lastKnownVersionID = entry.VersionID
+	p.deployedKey = entry.Key
+	if entry.PreviousTarget != "" {
+		p.previousModel = entry.PreviousTarget
+	}
+	p.deployedModel = entry.ModelPath
+	p.deployedSHA256 = entry.SHA256
+	p.deployedSignedVersion = entry.SignedVersion
+	p.deployedAt = time.Now()
+	p.lastError = ""
 	p.mu.Unlock()
 
+	// Yeni durumu diske yaz ki yeniden başlatmada kaybolmasın.
+	if err := p.saveState(); err != nil {
+		return fmt.Errorf("dağıtım başarılı ancak durum kaydedilemedi: %w", err)
+	}
+	if err := p.writeJournal(entry, PhaseCommitted); err != nil {
+		return err
+	}
+	p.clearJournal()
+
+	// Saklama politikası, yeni model stabil olduktan sonra uygulanır.
+	// Temizlik hatası dağıtımı başarısız saymaz.
+	if _, err := p.collectGarbage(); err != nil {
+		p.log.Printf("[GC] UYARI: Eski sürümler temizlenemedi: %v", err)
+	}
+	return nil
+}
+
+// rollback, sembolik bağı eski modele geri çevirir ve servisi eski modelle
+// yeniden başlatır. Başarılı olursa dağıtım günlüğü temizlenir; başarısız olursa
+// günlük yerinde bırakılır ki bir sonraki başlangıçta Recover() tekrar denesin.
+func (p *Poller) rollback(oldModelTarget string) error {
+	p.log.Println("[Poller] OTOMATİK ROLLBACK BAŞLATILIYOR...")
+
+	if oldModelTarget == "" {
+		return fmt.Errorf("ROLLBACK BAŞARISIZ: Eski modelin yolu bilinmiyor")
+	}
+
+	// Sembolik bağı acilen ESKİ modele geri çevir.
+	errRollback := p.linker.Set(oldModelTarget, p.activeModelPath)
+	if errRollback != nil {
+		// Bu olursa çok büyük felaket (sistem "down" kalır)
+		return fmt.Errorf("KRİTİK HATA! Rollback sırasında sembolik bağ değiştirilemedi: %w", errRollback)
+	}
+
+	// Servisi ESKİ modelle tekrar başlat.
+	errReloadOld := p.deploy.Run(p.cfg.DeployScriptPath, "--reload")
+	if errReloadOld != nil {
+		return fmt.Errorf("KRİTİK HATA! Rollback başarılı ancak servis eski modelle de başlatılamadı: %w", errReloadOld)
+	}
+
+	p.log.Println("[Poller] ROLLBACK BAŞARILI. Sistem eski stabil modele döndü.")
+	p.clearJournal()
 	return nil
 }
 
+// writeJournal, dağıtım kaydını verilen aşamaya ilerletir ve diske yazar.
+func (p *Poller) writeJournal(entry *JournalEntry, phase JournalPhase) error {
+	entry.Phase = phase
+	entry.Time = time.Now()
+	if err := p.store.AppendJournal(*entry); err != nil {
+		return fmt.Errorf("dağıtım günlüğüne yazılamadı (%s): %w", phase, err)
+	}
+	return nil
+}
+
+// clearJournal, tamamlanan veya iptal edilen bir dağıtımın günlüğünü temizler.
+func (p *Poller) clearJournal() {
+	if err := p.store.ClearJournal(); err != nil {
+		p.log.Printf("[Poller] UYARI: Dağıtım günlüğü temizlenemedi: %v", err)
+	}
+}
+
+// saveState, Poller'ın mevcut durumunu kalıcı depoya yazar.
+func (p *Poller) saveState() error {
+	p.mu.RLock()
+	state := &AgentState{
+		ETag:            p.lastKnownETag,
+		VersionID:       p.lastKnownVersionID,
+		Key:             p.deployedKey,
+		ActiveModelPath: p.deployedModel,
+		PreviousModel:   p.previousModel,
+		SHA256:          p.deployedSHA256,
+		SignedVersion:   p.deployedSignedVersion,
+		DeployedAt:      p.deployedAt,
+		LastError:       p.lastError,
+		Quarantine:      make(map[string]QuarantineEntry, len(p.quarantined)),
+	}
+	for etag, entry := range p.quarantined {
+		state.Quarantine[etag] = entry
+	}
+	p.mu.RUnlock()
+	return p.store.Save(state)
+}
+
+// recordError, bir döngüde alınan hatayı kalıcı duruma kaydeder.
+func (p *Poller) recordError(runErr error) {
+	p.mu.Lock()
+	p.lastError = runErr.Error()
+	p.mu.Unlock()
+	if err := p.saveState(); err != nil {
+		p.log.Printf("[Poller] UYARI: Hata durumu kaydedilemedi: %v", err)
+	}
+}
+
 // GetStatus, Poller'ın mevcut bilinen ETag'ini thread-safe bir şekilde döndürür.
 func (p *Poller) GetStatus() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	return p.lastKnownETag
 }
+
+// PollerStatus, durum panelinde gösterilen Poller bilgileridir.
+type PollerStatus struct {
+	Name          string                     // Hedefin adı (tek model yönetiliyorsa boş)
+	ETag          string                     // Stabil (commit edilmiş) modelin ETag'i
+	VersionID     string                     // Stabil modelin S3 VersionID'si (versiyonlama kapalıysa boş)
+	Key           string                     // Stabil modelin S3 anahtarı
+	ModelPath     string                     // Stabil modelin yolu
+	SHA256        string                     // Stabil modelin SHA-256 özeti
+	DeployedAt    time.Time                  // Son başarılı dağıtım zamanı
+	LastError     string                     // Son döngüde alınan hata
+	State         string                     // "stable" veya "soaking"
+	SoakVersion   string                     // İzlenen modelin revizyonu (sadece "soaking" durumunda)
+	SoakRemaining time.Duration              // İzlemenin bitmesine kalan süre
+	Quarantine    map[string]QuarantineEntry // Revizyon kimliği -> karantina kaydı
+	Disk          DiskStatus                 // Son disk alanı kontrolü
+}
+
+// Status, Poller'ın ayrıntılı durumunu thread-safe bir şekilde döndürür.
+func (p *Poller) Status() PollerStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := PollerStatus{
+		Name:       p.cfg.Name,
+		ETag:       p.lastKnownETag,
+		VersionID:  p.lastKnownVersionID,
+		Key:        p.deployedKey,
+		ModelPath:  p.deployedModel,
+		SHA256:     p.deployedSHA256,
+		DeployedAt: p.deployedAt,
+		LastError:  p.lastError,
+		Disk:       p.disk,
+		State:      "stable",
+		Quarantine: make(map[string]QuarantineEntry, len(p.quarantined)),
+	}
+	for etag, entry := range p.quarantined {
+		status.Quarantine[etag] = entry
+	}
+	if p.soak != nil {
+		status.State = "soaking"
+		status.SoakVersion = p.soak.ID()
+		status.SoakRemaining = max(time.Until(p.soak.SoakUntil), 0).Round(time.Second)
+	}
+	return status
+}
@@ -1,9 +1,19 @@
 package main
 
 import (
-	"fmt"     // Hata oluşturmak için
+	"context" // MockHealthProber imzası için
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt" // Hata oluşturmak için
+	"os"
+	"path/filepath"
 	"strings" // Çağrıları kaydetmek için
+	"sync"
 	"testing" // Test kütüphanesi
+	"time"
 )
 
 // --- SAHTE BİLEŞENLER (MOCKS) ---
@@ -14,16 +24,86 @@ import (
 type MockS3Client struct {
 	// Bu alanları testin içinde biz ayarlayacağız.
 	// Poller bu fonksiyonu çağırdığında, bu değerleri döndürecek.
-	EtagToReturn string
-	ErrToReturn  error
+	EtagToReturn      string
+	VersionIDToReturn string
+	ErrToReturn       error
+	// HeadObject'e en son verilen VersionID (sabitleme testleri için).
+	RequestedVersionID string
+	// Yayınlanmış SHA-256 özeti ve indirilen dosyanın "gerçek" özeti.
+	ExpectedSHA256ToReturn string
+	DigestToReturn         string
+	DownloadCalls          int
+	// FetchObject ile okunabilecek küçük nesneler (anahtar -> içerik).
+	Objects map[string][]byte
+	// ListObjects ile döndürülecek nesneler (prefix izleme testleri için).
+	// HeadObject, listede bulunan bir anahtar için o nesneyi döndürür.
+	Listing []ObjectVersion
+
+	mu sync.Mutex
+}
+
+func (m *MockS3Client) HeadObject(bucket, key, versionID string) (ObjectVersion, error) {
+	// Delta nesneleri sadece Objects içinde varsa bulunur.
+	if strings.HasSuffix(key, deltaSuffix) {
+		content, ok := m.Objects[key]
+		if !ok {
+			return ObjectVersion{}, ErrObjectNotFound
+		}
+		return ObjectVersion{Key: key, Size: int64(len(content))}, nil
+	}
+	m.RequestedVersionID = versionID
+	for _, obj := range m.Listing {
+		if obj.Key == key {
+			return obj, m.ErrToReturn
+		}
+	}
+	return ObjectVersion{Key: key, ETag: m.EtagToReturn, VersionID: m.VersionIDToReturn}, m.ErrToReturn
+}
+
+func (m *MockS3Client) ListObjects(bucket, prefix string) ([]ObjectVersion, error) {
+	var objects []ObjectVersion
+	for _, obj := range m.Listing {
+		if strings.HasPrefix(obj.Key, prefix) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, m.ErrToReturn
 }
 
-func (m *MockS3Client) HeadObject(bucket, key string) (string, error) {
-	return m.EtagToReturn, m.ErrToReturn
+func (m *MockS3Client) ExpectedSHA256(bucket string, obj ObjectVersion) (string, error) {
+	return m.ExpectedSHA256ToReturn, m.ErrToReturn
 }
 
-func (m *MockS3Client) DownloadObject(bucket, key, destinationPath string) error {
-	return m.ErrToReturn
+func (m *MockS3Client) DownloadObject(bucket string, obj ObjectVersion, destinationPath, expectedSHA256 string) (string, error) {
+	m.mu.Lock()
+	m.DownloadCalls++ // Paket dosyaları paralel indirilir.
+	m.mu.Unlock()
+	if m.ErrToReturn != nil {
+		return "", m.ErrToReturn
+	}
+	// Objects içinde olan nesneler gerçekten diske yazılır (paket testleri için).
+	if content, ok := m.Objects[obj.Key]; ok {
+		sum := sha256.Sum256(content)
+		digest := hex.EncodeToString(sum[:])
+		if err := verifySHA256(expectedSHA256, digest); err != nil {
+			return "", err
+		}
+		os.MkdirAll(filepath.Dir(destinationPath), 0o755)
+		return digest, os.WriteFile(destinationPath, content, 0o644)
+	}
+	// Gerçek istemci gibi, özet eşleşmezse dosyayı reddet.
+	if err := verifySHA256(expectedSHA256, m.DigestToReturn); err != nil {
+		return "", err
+	}
+	return m.DigestToReturn, nil
+}
+
+func (m *MockS3Client) FetchObject(bucket, key string) ([]byte, error) {
+	content, ok := m.Objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return content, nil
 }
 
 // MockDeployer, Deployer arayüzünü taklit eder.
@@ -33,6 +113,8 @@ type MockDeployer struct {
 	FailOnArgs []string
 	// Yaptığı tüm çağrıları kaydeder (testin sonunda kontrol etmek için)
 	Calls []string
+	// Output çağrısında döndürülecek çıktı (örn: "--error-rate" için "0.01")
+	OutputToReturn string
 }
 
 func (m *MockDeployer) Run(scriptPath string, args ...string) error {
@@ -54,6 +136,13 @@ func (m *MockDeployer) Run(scriptPath string, args ...string) error {
 	return nil // Başarılı
 }
 
+func (m *MockDeployer) Output(scriptPath string, args ...string) (string, error) {
+	if err := m.Run(scriptPath, args...); err != nil {
+		return "", err
+	}
+	return m.OutputToReturn, nil
+}
+
 // MockLinker, Linker arayüzünü taklit eder.
 type MockLinker struct {
 	CurrentTarget string // Şu anki hedefi (eski model)
@@ -71,6 +160,60 @@ func (m *MockLinker) Get(linkName string) (string, error) {
 	return m.CurrentTarget, nil
 }
 
+// MockStateStore, StateStore arayüzünü taklit eder. Durumu bellekte tutar.
+type MockStateStore struct {
+	State     *AgentState // Load çağrısında döndürülecek durum (nil ise boş durum)
+	SaveCalls int
+	Journal   []JournalEntry // Temizlenmemiş günlük kayıtları
+	Phases    []JournalPhase // Yazılan tüm aşamalar (temizlense de silinmez)
+}
+
+func (m *MockStateStore) Load() (*AgentState, error) {
+	if m.State == nil {
+		return &AgentState{}, nil
+	}
+	return m.State, nil
+}
+
+func (m *MockStateStore) Save(state *AgentState) error {
+	m.SaveCalls++
+	m.State = state
+	return nil
+}
+
+func (m *MockStateStore) AppendJournal(entry JournalEntry) error {
+	m.Journal = append(m.Journal, entry)
+	m.Phases = append(m.Phases, entry.Phase)
+	return nil
+}
+
+func (m *MockStateStore) LastJournal() (*JournalEntry, error) {
+	if len(m.Journal) == 0 {
+		return nil, nil
+	}
+	last := m.Journal[len(m.Journal)-1]
+	return &last, nil
+}
+
+func (m *MockStateStore) ClearJournal() error {
+	m.Journal = nil
+	return nil
+}
+
+// MockHealthProber, HealthProber arayüzünü taklit eder.
+type MockHealthProber struct {
+	FailCount int // İlk kaç kontrolün başarısız olacağı
+	Calls     int
+}
+
+func (m *MockHealthProber) Probe(ctx context.Context, check HealthCheck) error {
+	m.Calls++
+	if m.Calls <= m.FailCount {
+		return fmt.Errorf("MockHealthProber: '%s' kontrolü başarısız olmaya ayarlandı", check.Type)
+	}
+	return nil
+}
+
 // --- TEST FONKSİYONLARI (BAŞARI KRİTERİ) ---
 
 // TestPoller_HappyPath (Mutlu Son Senaryosu)
@@ -78,6 +221,7 @@ func (m *MockLinker) Get(linkName string) (string, error) {
 func TestPoller_HappyPath(t *testing.T) {
 	// 1. Hazırlık (Setup)
 	mockCfg := &Config{
+		DataDir:          "/var/lib/edgesync",
 		S3Bucket:         "test-bucket",
 		S3Key:            "model.bin",
 		DeployScriptPath: "deploy.sh",
@@ -88,7 +232,7 @@ func TestPoller_HappyPath(t *testing.T) {
 
 	activeModelLink := "/var/lib/edgesync/active_model"
 
-	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, activeModelLink)
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, &MockStateStore{}, &MockHealthProber{}, activeModelLink)
 	p.lastKnownETag = "v1-old-model" // Ajan "v1" i biliyor
 
 	// 2. Çalıştırma (Execute)
@@ -137,6 +281,7 @@ func TestPoller_HappyPath(t *testing.T) {
 func TestPoller_RollbackPath(t *testing.T) {
 	// 1. Hazırlık (Setup)
 	mockCfg := &Config{
+		DataDir:          "/var/lib/edgesync",
 		S3Bucket:         "test-bucket",
 		S3Key:            "model.bin",
 		DeployScriptPath: "deploy.sh",
@@ -151,7 +296,7 @@ func TestPoller_RollbackPath(t *testing.T) {
 
 	activeModelLink := "/var/lib/edgesync/active_model"
 
-	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, activeModelLink)
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, &MockStateStore{}, &MockHealthProber{}, activeModelLink)
 	p.lastKnownETag = "v1-old-model" // Ajan "v1" i biliyor
 
 	// 2. Çalıştırma (Execute)
@@ -198,7 +343,7 @@ func TestPoller_NoChange(t *testing.T) {
 	mockDeploy := &MockDeployer{}
 	mockLink := &MockLinker{}
 
-	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, "")
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, &MockStateStore{}, &MockHealthProber{}, "")
 	p.lastKnownETag = "v1-model" // Ajan zaten "v1" i biliyor
 
 	// 2. Çalıştırma (Execute)
@@ -216,3 +361,769 @@ func TestPoller_NoChange(t *testing.T) {
 		t.Errorf("Linker.Set() çağrılmamalıydı, ancak %d kez çağrıldı", len(mockLink.Calls))
 	}
 }
+
+// TestPoller_VersionID, versiyonlama açık bir bucket'ta aynı içeriğin yeniden
+// yüklenmesinin (ETag aynı, VersionID farklı) yeni bir sürüm sayıldığını test eder.
+func TestPoller_VersionID(t *testing.T) {
+	mockCfg := &Config{DataDir: "/data", DeployScriptPath: "deploy.sh"}
+	mockS3 := &MockS3Client{EtagToReturn: "same-etag", VersionIDToReturn: "ver/2"}
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "same-etag", VersionID: "ver1"}}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, "/data/active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	// VersionID dosya adında güvenli karakterlere çevrilmeli.
+	expectedPath := "/data/models/model-ver_2.bin"
+	if len(mockLink.Calls) != 1 || mockLink.Calls[0] != "SET /data/active_model -> "+expectedPath {
+		t.Errorf("Yeni revizyon deploy edilmeliydi, linker çağrıları: %v", mockLink.Calls)
+	}
+	if mockStore.State.VersionID != "ver/2" {
+		t.Errorf("Kaydedilen VersionID 'ver/2' olmalıydı, ancak '%s' oldu", mockStore.State.VersionID)
+	}
+
+	// Aynı VersionID tekrar geldiğinde hiçbir şey yapılmamalı.
+	mockLink.Calls = nil
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if len(mockLink.Calls) != 0 {
+		t.Errorf("Değişmeyen revizyon tekrar deploy edilmemeliydi, çağrılar: %v", mockLink.Calls)
+	}
+}
+
+// TestPoller_VersionPin, 's3_version_id' ayarının HeadObject'e iletildiğini test eder.
+func TestPoller_VersionPin(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", S3VersionID: "pinned"}
+	mockS3 := &MockS3Client{EtagToReturn: "v1", VersionIDToReturn: "pinned"}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, "active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if mockS3.RequestedVersionID != "pinned" {
+		t.Errorf("HeadObject sabitlenen revizyonla çağrılmalıydı, ancak '%s' ile çağrıldı", mockS3.RequestedVersionID)
+	}
+}
+
+// TestPoller_PrefixWatch, prefix izleme modunda en yeni sürümün seçilip
+// deploy edildiğini ve imzanın seçilen anahtarın yanından okunduğunu test eder.
+func TestPoller_PrefixWatch(t *testing.T) {
+	root := t.TempDir()
+	mockCfg := &Config{DataDir: root, DeployScriptPath: "deploy.sh", S3Prefix: "models/", S3KeyPattern: `^models/v[^/]+/model\.bin$`}
+	mockS3 := &MockS3Client{Listing: []ObjectVersion{
+		{Key: "models/v1.4.0/model.bin", ETag: "e140"},
+		{Key: "models/v1.10.0/model.bin", ETag: "e1100"},
+		{Key: "models/v1.10.0/model.bin.sha256", ETag: "sidecar"},
+		{Key: "models/v1.10.1/tokenizer.json", ETag: "tokenizer"},
+		{Key: "models/v1.9.2/model.bin", ETag: "e192"},
+	}}
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "e140", Key: "models/v1.4.0/model.bin"}}
+
+	link := filepath.Join(root, "active_model")
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, link)
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if mockStore.State.ETag != "e1100" || mockStore.State.Key != "models/v1.10.0/model.bin" {
+		t.Errorf("En yeni sürüm (v1.10.0) deploy edilmeliydi, durum: %+v", mockStore.State)
+	}
+	if len(mockLink.Calls) != 1 || mockLink.Calls[0] != "SET "+link+" -> "+filepath.Join(root, modelsSubdir, "model-e1100.bin") {
+		t.Errorf("Beklenmedik linker çağrıları: %v", mockLink.Calls)
+	}
+}
+
+// TestPoller_PrefixRequiresPattern, prefix izleme modunda desen verilmezse yan
+// dosyaların model sanılmaması için hiçbir sürümün seçilmediğini test eder.
+func TestPoller_PrefixRequiresPattern(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", S3Prefix: "models/"}
+	mockS3 := &MockS3Client{Listing: []ObjectVersion{{Key: "models/v1.4.0/model.bin", ETag: "e140"}}}
+	mockLink := &MockLinker{}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, mockLink, &MockStateStore{}, &MockHealthProber{}, "active_model")
+	if err := p.RunOnce(); err == nil || !strings.Contains(err.Error(), "s3_key_pattern") {
+		t.Errorf("'s3_key_pattern' hatası bekleniyordu, alınan: %v", err)
+	}
+	if len(mockLink.Calls) != 0 {
+		t.Errorf("Hiçbir model bağlanmamalıydı: %v", mockLink.Calls)
+	}
+}
+
+// TestPoller_KeyChangeIsNewVersion, ETag aynı olsa bile anahtarı değişen
+// bir nesnenin yeni bir sürüm sayıldığını test eder.
+func TestPoller_KeyChangeIsNewVersion(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", S3Key: "models/v2/model.bin"}
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "same-etag", Key: "models/v1/model.bin"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "same-etag"}, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if len(mockLink.Calls) != 1 || mockStore.State.Key != "models/v2/model.bin" {
+		t.Errorf("Yeni anahtardaki model deploy edilmeliydi, durum: %+v, çağrılar: %v", mockStore.State, mockLink.Calls)
+	}
+}
+
+// TestPoller_StateSurvivesRestart (Yeniden Başlatma Senaryosu)
+// Ajan yeniden başladı; kayıtlı durumda "v1" var, S3'te "v2" var.
+// İlk RunOnce, "v2"yi sadece öğrenmemeli, doğrudan deploy etmeli.
+func TestPoller_StateSurvivesRestart(t *testing.T) {
+	// 1. Hazırlık (Setup)
+	mockCfg := &Config{DataDir: "/var/lib/edgesync", S3Bucket: "test-bucket", S3Key: "model.bin", DeployScriptPath: "deploy.sh"}
+	mockS3 := &MockS3Client{EtagToReturn: "v2-new-model"}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{CurrentTarget: "/var/lib/edgesync/models/model-v1-old-model.bin"}
+	mockStore := &MockStateStore{State: &AgentState{
+		ETag:            "v1-old-model",
+		ActiveModelPath: "/var/lib/edgesync/models/model-v1-old-model.bin",
+	}}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, mockStore, &MockHealthProber{}, "/var/lib/edgesync/active_model")
+	if p.GetStatus() != "v1-old-model" {
+		t.Fatalf("Kayıtlı ETag yüklenmeliydi, ancak '%s' alındı", p.GetStatus())
+	}
+
+	// 2. Çalıştırma (Execute)
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	// 3. Doğrulama (Assert)
+	if len(mockDeploy.Calls) != 2 {
+		t.Fatalf("Yeniden başlatma sonrası yeni model deploy edilmeliydi, çağrılar: %v", mockDeploy.Calls)
+	}
+	if mockStore.SaveCalls != 1 {
+		t.Errorf("Başarılı dağıtımdan sonra durum 1 kez kaydedilmeliydi, ancak %d kez kaydedildi", mockStore.SaveCalls)
+	}
+	if mockStore.State.ETag != "v2-new-model" {
+		t.Errorf("Kaydedilen ETag 'v2-new-model' olmalıydı, ancak '%s' oldu", mockStore.State.ETag)
+	}
+	if mockStore.State.ActiveModelPath != "/var/lib/edgesync/models/model-v2-new-model.bin" {
+		t.Errorf("Kaydedilen aktif model yolu hatalı: '%s'", mockStore.State.ActiveModelPath)
+	}
+	if mockStore.State.DeployedAt.IsZero() {
+		t.Error("Kaydedilen dağıtım zamanı boş olmamalıydı")
+	}
+}
+
+// TestPoller_RollbackRecordsError
+// Rollback olduğunda ETag değişmemeli, ancak hata kalıcı duruma yazılmalı.
+func TestPoller_RollbackRecordsError(t *testing.T) {
+	mockCfg := &Config{DataDir: "/var/lib/edgesync", S3Bucket: "test-bucket", S3Key: "model.bin", DeployScriptPath: "deploy.sh"}
+	mockS3 := &MockS3Client{EtagToReturn: "v2-new-model"}
+	mockDeploy := &MockDeployer{FailOnArgs: []string{"--reload"}}
+	mockLink := &MockLinker{CurrentTarget: "/var/lib/models/model-v1.bin"}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1-old-model"}}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, mockLink, mockStore, &MockHealthProber{}, "/var/lib/edgesync/active_model")
+	if err := p.RunOnce(); err == nil {
+		t.Fatal("RunOnce() hata döndürmeliydi (Rollback), ancak nil döndürdü.")
+	}
+
+	if mockStore.State.ETag != "v1-old-model" {
+		t.Errorf("Kaydedilen ETag değişmemeliydi, ancak '%s' oldu", mockStore.State.ETag)
+	}
+	if !strings.Contains(mockStore.State.LastError, "rollback yapıldı") {
+		t.Errorf("Son hata kaydedilmeliydi, ancak '%s' kaydedildi", mockStore.State.LastError)
+	}
+}
+
+// TestPoller_JournalPhases
+// Başarılı bir dağıtım, tüm aşamaları sırayla günlüğe yazmalı ve sonunda günlüğü temizlemeli.
+func TestPoller_JournalPhases(t *testing.T) {
+	mockCfg := &Config{DataDir: "/var/lib/edgesync", S3Bucket: "test-bucket", S3Key: "model.bin", DeployScriptPath: "deploy.sh"}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1-old-model"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2-new-model"}, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, "/var/lib/edgesync/active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	expected := []JournalPhase{PhaseDownloaded, PhaseTested, PhaseLinked, PhaseReloaded, PhaseCommitted}
+	if fmt.Sprint(mockStore.Phases) != fmt.Sprint(expected) {
+		t.Errorf("Günlük aşamaları hatalı.\nBeklenen: %v\nAlınan:   %v", expected, mockStore.Phases)
+	}
+	if len(mockStore.Journal) != 0 {
+		t.Errorf("Dağıtım sonunda günlük temizlenmeliydi, ancak %d kayıt kaldı", len(mockStore.Journal))
+	}
+}
+
+// TestPoller_RecoverFromLinked
+// Süreç, sembolik bağ değiştikten sonra ama reload'dan önce öldü.
+// Recover() eski modele geri dönmeli.
+func TestPoller_RecoverFromLinked(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh"}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{CurrentTarget: "models/model-v2.bin"}
+	mockStore := &MockStateStore{
+		State: &AgentState{ETag: "v1"},
+		Journal: []JournalEntry{
+			{Phase: PhaseLinked, ETag: "v2", ModelPath: "models/model-v2.bin", PreviousTarget: "models/model-v1.bin"},
+		},
+	}
+
+	p := NewPoller(mockCfg, &MockS3Client{}, mockDeploy, mockLink, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.Recover(); err != nil {
+		t.Fatalf("Recover() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if mockLink.CurrentTarget != "models/model-v1.bin" {
+		t.Errorf("Sembolik bağ eski modele dönmeliydi, ancak '%s' gösteriyor", mockLink.CurrentTarget)
+	}
+	if len(mockDeploy.Calls) != 1 || mockDeploy.Calls[0] != "deploy.sh --reload" {
+		t.Errorf("Servis eski modelle bir kez yeniden başlatılmalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	if p.GetStatus() != "v1" {
+		t.Errorf("ETag değişmemeliydi ('v1'), ancak '%s' oldu", p.GetStatus())
+	}
+	if len(mockStore.Journal) != 0 {
+		t.Errorf("Kurtarma sonrası günlük temizlenmeliydi")
+	}
+}
+
+// TestPoller_RecoverFromTestedAfterLink
+// Süreç, sembolik bağ değiştikten sonra ama PhaseLinked günlüğe yazılmadan öldü.
+// Son kayıt PhaseTested olsa da bağ yeni modeli gösterdiği için Recover()
+// eski modele geri dönmeli.
+func TestPoller_RecoverFromTestedAfterLink(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh"}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{CurrentTarget: "models/model-v2.bin"}
+	mockStore := &MockStateStore{
+		State: &AgentState{ETag: "v1"},
+		Journal: []JournalEntry{
+			{Phase: PhaseTested, ETag: "v2", ModelPath: "models/model-v2.bin", PreviousTarget: "models/model-v1.bin"},
+		},
+	}
+
+	p := NewPoller(mockCfg, &MockS3Client{}, mockDeploy, mockLink, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.Recover(); err != nil {
+		t.Fatalf("Recover() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if mockLink.CurrentTarget != "models/model-v1.bin" {
+		t.Errorf("Sembolik bağ eski modele dönmeliydi, ancak '%s' gösteriyor", mockLink.CurrentTarget)
+	}
+	if len(mockDeploy.Calls) != 1 || mockDeploy.Calls[0] != "deploy.sh --reload" {
+		t.Errorf("Servis eski modelle bir kez yeniden başlatılmalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	if p.GetStatus() != "v1" {
+		t.Errorf("ETag değişmemeliydi ('v1'), ancak '%s' oldu", p.GetStatus())
+	}
+	if len(mockStore.Journal) != 0 {
+		t.Errorf("Kurtarma sonrası günlük temizlenmeliydi")
+	}
+}
+
+// TestPoller_RecoverFromReloaded
+// Süreç, servis yeni modelle başladıktan sonra ama durum kaydedilmeden öldü.
+// Recover() dağıtımı tamamlamalı.
+func TestPoller_RecoverFromReloaded(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh"}
+	mockDeploy := &MockDeployer{}
+	mockStore := &MockStateStore{
+		State: &AgentState{ETag: "v1"},
+		Journal: []JournalEntry{
+			{Phase: PhaseReloaded, ETag: "v2", ModelPath: "models/model-v2.bin", PreviousTarget: "models/model-v1.bin"},
+		},
+	}
+
+	p := NewPoller(mockCfg, &MockS3Client{}, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.Recover(); err != nil {
+		t.Fatalf("Recover() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	if len(mockDeploy.Calls) != 0 {
+		t.Errorf("Servis tekrar başlatılmamalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	if mockStore.State.ETag != "v2" || mockStore.State.ActiveModelPath != "models/model-v2.bin" {
+		t.Errorf("Dağıtım kalıcı duruma işlenmeliydi, ancak durum: %+v", mockStore.State)
+	}
+}
+
+// TestPoller_HealthCheckRollback
+// Reload başarılı ama servis sağlık kontrolünden geçemiyor. Rollback yapılmalı.
+func TestPoller_HealthCheckRollback(t *testing.T) {
+	mockCfg := &Config{
+		DataDir:          "/var/lib/edgesync",
+		S3Bucket:         "test-bucket",
+		S3Key:            "model.bin",
+		DeployScriptPath: "deploy.sh",
+		Health: HealthConfig{
+			Checks:  []HealthCheck{{Type: "http", URL: "http://localhost:9000/health"}},
+			Retries: 2,
+			// Denemeler arası beklemeyi kısa tut ki test hızlı bitsin.
+			IntervalSeconds: 1,
+		},
+	}
+	mockDeploy := &MockDeployer{}
+	eskiModelYolu := "/var/lib/models/model-v1.bin"
+	mockLink := &MockLinker{CurrentTarget: eskiModelYolu}
+	mockProber := &MockHealthProber{FailCount: 2} // İki deneme de başarısız
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2-new-model"}, mockDeploy, mockLink, &MockStateStore{}, mockProber, "/var/lib/edgesync/active_model")
+	p.lastKnownETag = "v1-old-model"
+
+	err := p.RunOnce()
+	if err == nil || !strings.Contains(err.Error(), "sağlık kontrolü hatası") {
+		t.Fatalf("RunOnce() sağlık kontrolü hatası döndürmeliydi, alınan: %v", err)
+	}
+	if mockProber.Calls != 2 {
+		t.Errorf("Sağlık kontrolü 2 kez denenmeliydi, ancak %d kez denendi", mockProber.Calls)
+	}
+	// test, reload (yeni), reload (rollback)
+	if len(mockDeploy.Calls) != 3 {
+		t.Errorf("Deployer.Run() 3 kez çağrılmalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	if mockLink.CurrentTarget != eskiModelYolu {
+		t.Errorf("Rollback sonrası Linker hedefi ESKİ model olmalıydı, ancak '%s' oldu", mockLink.CurrentTarget)
+	}
+	if p.lastKnownETag != "v1-old-model" {
+		t.Errorf("Poller'ın son ETag'i değişmemeliydi, ancak '%s' oldu", p.lastKnownETag)
+	}
+}
+
+// TestPoller_HealthCheckRecovers
+// İlk sağlık kontrolü başarısız, ikincisi başarılı. Dağıtım tamamlanmalı.
+func TestPoller_HealthCheckRecovers(t *testing.T) {
+	mockCfg := &Config{
+		DeployScriptPath: "deploy.sh",
+		Health: HealthConfig{
+			Checks:          []HealthCheck{{Type: "tcp", Address: "localhost:9000"}},
+			Retries:         3,
+			IntervalSeconds: 1,
+		},
+	}
+	mockProber := &MockHealthProber{FailCount: 1}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, mockProber, "active_model")
+	p.lastKnownETag = "v1"
+
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if p.lastKnownETag != "v2" {
+		t.Errorf("Poller'ın son ETag'i 'v2' olmalıydı, ancak '%s' oldu", p.lastKnownETag)
+	}
+}
+
+// TestPoller_SoakCommitsAfterWindow
+// Yeni model izleme penceresine alınmalı, ETag ancak pencere dolunca commit edilmeli.
+func TestPoller_SoakCommitsAfterWindow(t *testing.T) {
+	mockCfg := &Config{
+		DeployScriptPath: "deploy.sh",
+		Soak:             SoakConfig{DurationSeconds: 900, MaxErrorRate: 0.05},
+	}
+	mockDeploy := &MockDeployer{OutputToReturn: "0.01\n"}
+	mockStore := &MockStateStore{}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+	p.lastKnownETag = "v1"
+
+	// 1. Döngü: dağıtım yapılır, model izlemeye alınır.
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	status := p.Status()
+	if status.State != "soaking" || status.SoakVersion != "v2" {
+		t.Fatalf("Model izleme penceresinde olmalıydı, durum: %+v", status)
+	}
+	if status.ETag != "v1" {
+		t.Errorf("İzleme bitmeden ETag commit edilmemeliydi, ancak '%s' oldu", status.ETag)
+	}
+	if status.SoakRemaining <= 0 {
+		t.Errorf("Kalan izleme süresi pozitif olmalıydı, ancak %v oldu", status.SoakRemaining)
+	}
+
+	// 2. Döngü: pencere henüz dolmadı, model hâlâ izlemede.
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if p.Status().State != "soaking" {
+		t.Fatalf("Model hâlâ izleme penceresinde olmalıydı")
+	}
+
+	// 3. Döngü: pencere doldu (zamanı geri alarak simüle ediyoruz).
+	p.soak.SoakUntil = time.Now().Add(-time.Second)
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	status = p.Status()
+	if status.State != "stable" || status.ETag != "v2" {
+		t.Errorf("İzleme sonrası model stabil ve ETag 'v2' olmalıydı, durum: %+v", status)
+	}
+	if mockStore.State.ETag != "v2" {
+		t.Errorf("Kaydedilen ETag 'v2' olmalıydı, ancak '%s' oldu", mockStore.State.ETag)
+	}
+}
+
+// TestPoller_SoakErrorRateRollback
+// İzleme sırasında script'in bildirdiği hata oranı sınırı aşarsa rollback yapılmalı.
+func TestPoller_SoakErrorRateRollback(t *testing.T) {
+	mockCfg := &Config{
+		DeployScriptPath: "deploy.sh",
+		Soak:             SoakConfig{DurationSeconds: 900, MaxErrorRate: 0.05},
+	}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{CurrentTarget: "models/model-v1.bin"}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, mockDeploy, mockLink, &MockStateStore{}, &MockHealthProber{}, "active_model")
+	p.lastKnownETag = "v1"
+
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	// Hata oranı yükseldi.
+	mockDeploy.OutputToReturn = "0.30"
+	err := p.RunOnce()
+	if err == nil || !strings.Contains(err.Error(), "izleme hatası") {
+		t.Fatalf("RunOnce() izleme hatası döndürmeliydi, alınan: %v", err)
+	}
+	if mockLink.CurrentTarget != "models/model-v1.bin" {
+		t.Errorf("Rollback sonrası Linker hedefi ESKİ model olmalıydı, ancak '%s' oldu", mockLink.CurrentTarget)
+	}
+	status := p.Status()
+	if status.State != "stable" || status.ETag != "v1" {
+		t.Errorf("Rollback sonrası durum stabil ve ETag 'v1' olmalıydı, durum: %+v", status)
+	}
+}
+
+// TestPoller_SoakRollbackRetried
+// İzleme hatasından sonraki rollback başarısız olursa izleme durumu kapatılmamalı;
+// bir sonraki döngü, yeni model sağlıklı görünse bile rollback'i tekrar denemeli.
+func TestPoller_SoakRollbackRetried(t *testing.T) {
+	mockCfg := &Config{
+		DeployScriptPath: "deploy.sh",
+		Soak:             SoakConfig{DurationSeconds: 900, MaxErrorRate: 0.05},
+	}
+	mockDeploy := &MockDeployer{}
+	mockLink := &MockLinker{CurrentTarget: "models/model-v1.bin"}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, mockDeploy, mockLink, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	// Hata oranı yükseldi ve eski modelle yeniden başlatma bir kez başarısız oluyor.
+	mockDeploy.OutputToReturn = "0.30"
+	mockDeploy.FailOnArgs = []string{"--reload"}
+	if err := p.RunOnce(); err == nil {
+		t.Fatal("RunOnce() rollback hatası döndürmeliydi")
+	}
+	if p.Status().State != "soaking" {
+		t.Errorf("Rollback başarısızken izleme durumu korunmalıydı, durum: %+v", p.Status())
+	}
+	if last, _ := mockStore.LastJournal(); last == nil || last.Phase != PhaseSoaking {
+		t.Errorf("Günlük izleme aşamasında kalmalıydı, alınan: %+v", last)
+	}
+
+	// Hata oranı düzelmiş görünse de (eski modeli ölçüyor olabilir) rollback tamamlanmalı.
+	mockDeploy.OutputToReturn = "0.00"
+	err := p.RunOnce()
+	if err == nil || !strings.Contains(err.Error(), "rollback yapıldı") {
+		t.Fatalf("RunOnce() rollback'i tamamlamalıydı, alınan: %v", err)
+	}
+	status := p.Status()
+	if mockLink.CurrentTarget != "models/model-v1.bin" || status.State != "stable" || status.ETag != "v1" {
+		t.Errorf("Rollback sonrası eski model stabil olmalıydı, bağ: '%s', durum: %+v", mockLink.CurrentTarget, status)
+	}
+	if len(mockStore.Journal) != 0 {
+		t.Errorf("Rollback sonrası günlük temizlenmeliydi: %+v", mockStore.Journal)
+	}
+	if q := mockStore.State.Quarantine["v2"]; q.Failures != 1 {
+		t.Errorf("Model bir kez karantinaya alınmalıydı: %+v", q)
+	}
+}
+
+// TestPoller_SoakFailureWithoutPrevious
+// Dönülecek bir model yokken izleme bozulursa servis durdurulmalı ve hata kaydedilmeli.
+func TestPoller_SoakFailureWithoutPrevious(t *testing.T) {
+	mockCfg := &Config{
+		DeployScriptPath: "deploy.sh",
+		Soak:             SoakConfig{DurationSeconds: 900, MaxErrorRate: 0.05},
+	}
+	mockDeploy := &MockDeployer{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+
+	mockDeploy.OutputToReturn = "0.30"
+	mockDeploy.Calls = nil
+	err := p.RunOnce()
+	if err == nil || !strings.Contains(err.Error(), "servis durduruldu") {
+		t.Fatalf("RunOnce() servisin durdurulduğunu bildirmeliydi, alınan: %v", err)
+	}
+	if len(mockDeploy.Calls) != 2 || mockDeploy.Calls[1] != "deploy.sh --stop" {
+		t.Errorf("Servis 'deploy.sh --stop' ile durdurulmalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	status := p.Status()
+	if status.State == "soaking" || status.LastError == "" {
+		t.Errorf("İzleme kapanmalı ve hata kaydedilmeliydi, durum: %+v", status)
+	}
+	if len(mockStore.Journal) != 0 {
+		t.Errorf("Günlük temizlenmeliydi: %+v", mockStore.Journal)
+	}
+}
+
+// TestPoller_RecoverSoakingAfterPartialRollback
+// İzleme hatasından sonraki rollback, bağ eski modele çevrildikten sonra kesildi.
+// Yeniden başlatmada izleme sürdürülmemeli, rollback tamamlanmalı.
+func TestPoller_RecoverSoakingAfterPartialRollback(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", Soak: SoakConfig{DurationSeconds: 900}}
+	mockDeploy := &MockDeployer{}
+	mockStore := &MockStateStore{
+		State: &AgentState{ETag: "v1"},
+		Journal: []JournalEntry{
+			{Phase: PhaseSoaking, ETag: "v2", ModelPath: "models/model-v2.bin", PreviousTarget: "models/model-v1.bin", SoakUntil: time.Now().Add(time.Hour)},
+		},
+	}
+
+	p := NewPoller(mockCfg, &MockS3Client{}, mockDeploy, &MockLinker{CurrentTarget: "models/model-v1.bin"}, mockStore, &MockHealthProber{}, "active_model")
+	if err := p.Recover(); err != nil {
+		t.Fatalf("Recover() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if err := p.RunOnce(); err == nil || !strings.Contains(err.Error(), "rollback yapıldı") {
+		t.Fatalf("RunOnce() yarım kalan rollback'i tamamlamalıydı, alınan: %v", err)
+	}
+	if len(mockDeploy.Calls) != 1 || mockDeploy.Calls[0] != "deploy.sh --reload" {
+		t.Errorf("Servis eski modelle yeniden başlatılmalıydı, çağrılar: %v", mockDeploy.Calls)
+	}
+	if p.Status().State == "soaking" || len(mockStore.Journal) != 0 {
+		t.Errorf("İzleme ve günlük kapanmalıydı, durum: %+v", p.Status())
+	}
+}
+
+// TestPoller_QuarantineFailedVersion
+// Testi başarısız olan ETag karantinaya alınmalı ve sonraki döngüde tekrar indirilmemeli.
+// Operatör karantinayı temizleyince tekrar denenmeli.
+func TestPoller_QuarantineFailedVersion(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh"}
+	mockDeploy := &MockDeployer{FailOnArgs: []string{"--test"}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2-broken"}, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+
+	// 1. Döngü: test başarısız, ETag karantinaya alınır.
+	if err := p.RunOnce(); err == nil {
+		t.Fatal("RunOnce() test hatası döndürmeliydi, ancak nil döndürdü.")
+	}
+	q, ok := mockStore.State.Quarantine["v2-broken"]
+	if !ok || q.Failures != 1 || q.Reason == "" {
+		t.Fatalf("ETag 'v2-broken' karantinaya kaydedilmeliydi, durum: %+v", mockStore.State.Quarantine)
+	}
+
+	// 2. Döngü: aynı ETag, hiçbir şey çağrılmamalı.
+	mockDeploy.Calls = nil
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("Karantinadaki ETag için RunOnce() hata döndürmemeliydi: %v", err)
+	}
+	if len(mockDeploy.Calls) != 0 {
+		t.Errorf("Karantinadaki ETag tekrar test edilmemeliydi, çağrılar: %v", mockDeploy.Calls)
+	}
+
+	// 3. Operatör karantinayı temizler; bir sonraki döngüde tekrar denenir.
+	if err := p.ClearQuarantine("v2-broken"); err != nil {
+		t.Fatalf("ClearQuarantine() hata döndürdü: %v", err)
+	}
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if p.lastKnownETag != "v2-broken" {
+		t.Errorf("Karantina temizlendikten sonra model deploy edilmeliydi, ETag: '%s'", p.lastKnownETag)
+	}
+}
+
+// TestPoller_QuarantineThreshold
+// Eşik 2 iken ilk başarısızlıktan sonra ETag tekrar denenmeli, ikincisinden sonra atlanmalı.
+func TestPoller_QuarantineThreshold(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", QuarantineThreshold: 2}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, &MockDeployer{}, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+
+	for i := 1; i <= 3; i++ {
+		mockDeploy := &MockDeployer{FailOnArgs: []string{"--test"}}
+		p.deploy = mockDeploy
+		p.RunOnce()
+
+		tested := len(mockDeploy.Calls) > 0
+		if i <= 2 && !tested {
+			t.Errorf("%d. döngüde model test edilmeliydi", i)
+		}
+		if i == 3 && tested {
+			t.Errorf("%d. döngüde model karantinada olmalıydı, çağrılar: %v", i, mockDeploy.Calls)
+		}
+	}
+}
+
+// TestPoller_ChecksumMismatch
+// İndirilen dosyanın özeti yayınlanan özetle eşleşmiyor. Model test aşamasına
+// geçmemeli ve ETag karantinaya alınmalı.
+func TestPoller_ChecksumMismatch(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh"}
+	mockS3 := &MockS3Client{
+		EtagToReturn:           "v2",
+		ExpectedSHA256ToReturn: strings.Repeat("a", 64),
+		DigestToReturn:         strings.Repeat("b", 64),
+	}
+	mockDeploy := &MockDeployer{}
+
+	p := NewPoller(mockCfg, mockS3, mockDeploy, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, "active_model")
+	p.lastKnownETag = "v1"
+
+	err := p.RunOnce()
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("RunOnce() ErrChecksumMismatch döndürmeliydi, alınan: %v", err)
+	}
+	if len(mockDeploy.Calls) != 0 {
+		t.Errorf("Özeti eşleşmeyen model test edilmemeliydi, çağrılar: %v", mockDeploy.Calls)
+	}
+	if !p.isQuarantined("v2") {
+		t.Error("Özeti eşleşmeyen ETag karantinaya alınmalıydı")
+	}
+}
+
+// TestPoller_RequireSHA256
+// require_sha256 açıkken yayınlanmış özet yoksa model indirilmemeli.
+func TestPoller_RequireSHA256(t *testing.T) {
+	mockCfg := &Config{DeployScriptPath: "deploy.sh", RequireSHA256: true}
+	mockS3 := &MockS3Client{EtagToReturn: "v2"}
+
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &MockLinker{}, &MockStateStore{}, &MockHealthProber{}, "active_model")
+	p.lastKnownETag = "v1"
+
+	if err := p.RunOnce(); err == nil {
+		t.Fatal("RunOnce() özet eksik hatası döndürmeliydi, ancak nil döndürdü.")
+	}
+	if mockS3.DownloadCalls != 0 {
+		t.Errorf("Özeti olmayan model indirilmemeliydi")
+	}
+}
+
+// TestPoller_SignatureVerification
+// İmza doğrulaması açıkken: geçerli imza deploy edilir, imzasız model hiç
+// indirilmez, geçersiz imzalı veya aktif modelden eski sürüm numaralı model
+// test edilmeden karantinaya alınır.
+func TestPoller_SignatureVerification(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Anahtar üretilemedi: %v", err)
+	}
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+
+	digest := sha256.Sum256([]byte("model"))
+	digestHex := hex.EncodeToString(digest[:])
+	sign := func(key ed25519.PrivateKey, version uint64) []byte {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, signedMessage(digestHex, version)))
+		return []byte(fmt.Sprintf("untrusted comment: edgesync test\ntrusted comment: version=%d\n%s\n", version, sig))
+	}
+	// Eski imza biçimi: sadece özet imzalanmış, sürüm numarası yok.
+	digestOnly := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, digest[:])) + "\n")
+
+	tests := []struct {
+		name       string
+		objects    map[string][]byte
+		wantErr    bool
+		wantTested bool
+		wantDown   bool
+	}{
+		{"geçerli imza", map[string][]byte{"model.bin.sig": sign(priv, 8)}, false, true, true},
+		{"imza yok", nil, true, false, false},
+		{"yanlış anahtarla imza", map[string][]byte{"model.bin.sig": sign(otherPriv, 8)}, true, false, true},
+		{"eski sürüm numarası", map[string][]byte{"model.bin.sig": sign(priv, 6)}, true, false, true},
+		{"sürüm numarası yok", map[string][]byte{"model.bin.sig": digestOnly}, true, false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCfg := &Config{
+				S3Key:             "model.bin",
+				DeployScriptPath:  "deploy.sh",
+				SigningPublicKeys: []string{base64.StdEncoding.EncodeToString(pub)},
+			}
+			mockS3 := &MockS3Client{EtagToReturn: "v2", DigestToReturn: digestHex, Objects: tc.objects}
+			mockDeploy := &MockDeployer{}
+
+			// Aktif model 7 numaralı imzalı sürüm.
+			mockStore := &MockStateStore{State: &AgentState{ETag: "v1", SHA256: strings.Repeat("0", 64), SignedVersion: 7}}
+			p := NewPoller(mockCfg, mockS3, mockDeploy, &MockLinker{}, mockStore, &MockHealthProber{}, "active_model")
+			err := p.RunOnce()
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("RunOnce() hata beklentisi %v, alınan: %v", tc.wantErr, err)
+			}
+			if tested := len(mockDeploy.Calls) > 0; tested != tc.wantTested {
+				t.Errorf("Model test edilmesi beklentisi %v, çağrılar: %v", tc.wantTested, mockDeploy.Calls)
+			}
+			if downloaded := mockS3.DownloadCalls > 0; downloaded != tc.wantDown {
+				t.Errorf("Model indirilmesi beklentisi %v, alınan %v", tc.wantDown, downloaded)
+			}
+			if !tc.wantErr && mockStore.State.SignedVersion != 8 {
+				t.Errorf("İmzalı sürüm numarası kaydedilmeliydi, durum: %+v", mockStore.State)
+			}
+		})
+	}
+}
+
+// TestPoller_NamedTarget, birden fazla hedef varken modellerin hedefin adıyla
+// ayrılmış bir dizine indirildiğini test eder.
+func TestPoller_NamedTarget(t *testing.T) {
+	mockCfg := &Config{DataDir: "/data", Name: "detector", DeployScriptPath: "deploy.sh"}
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, "/data/detector_model_link")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if len(mockLink.Calls) != 1 || mockLink.Calls[0] != "SET /data/detector_model_link -> /data/models/detector/model-v2.bin" {
+		t.Errorf("Beklenmedik linker çağrıları: %v", mockLink.Calls)
+	}
+	if p.Status().Name != "detector" {
+		t.Errorf("Durum hedefin adını içermeliydi: %+v", p.Status())
+	}
+}
+
+// TestPoller_LinkOutsideDataDir, aktif bağ veri kök dizini dışında (mutlak
+// 'link_name') olduğunda modellerin yine 'data_dir' altına indirildiğini test eder.
+func TestPoller_LinkOutsideDataDir(t *testing.T) {
+	mockCfg := &Config{DataDir: "/data", Name: "detector", DeployScriptPath: "deploy.sh"}
+	mockLink := &MockLinker{}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	p := NewPoller(mockCfg, &MockS3Client{EtagToReturn: "v2"}, &MockDeployer{}, mockLink, mockStore, &MockHealthProber{}, "/opt/app/current_model")
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if len(mockLink.Calls) != 1 || mockLink.Calls[0] != "SET /opt/app/current_model -> /data/models/detector/model-v2.bin" {
+		t.Errorf("Model veri kök dizinine indirilmeliydi, linker çağrıları: %v", mockLink.Calls)
+	}
+}
+
+// TestPoller_RelativeDataDir, göreli bir 'data_dir' ile yazılan gerçek
+// sembolik bağın (hedefi bağın dizinine göre çözüldüğünde) modeli gösterdiğini test eder.
+func TestPoller_RelativeDataDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	mockCfg := &Config{DataDir: "data", S3Key: "prod/model.bin", DeployScriptPath: "deploy.sh"}
+	mockS3 := &MockS3Client{EtagToReturn: "v2", Objects: map[string][]byte{"prod/model.bin": []byte("model")}}
+	mockStore := &MockStateStore{State: &AgentState{ETag: "v1"}}
+
+	link := mockCfg.ActiveLinkPath()
+	p := NewPoller(mockCfg, mockS3, &MockDeployer{}, &RealLinker{}, mockStore, &MockHealthProber{}, link)
+	if err := p.RunOnce(); err != nil {
+		t.Fatalf("RunOnce() beklenmedik bir hata döndürdü: %v", err)
+	}
+	got, err := os.ReadFile(link)
+	if err != nil || string(got) != "model" {
+		t.Errorf("Aktif bağ üzerinden model okunabilmeliydi, alınan: '%s' (%v)", got, err)
+	}
+}
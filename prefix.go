@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix izleme modunda en yeni sürümün nasıl seçileceği ('s3_select_by').
+const (
+	SelectBySemver       = "semver"        // Anahtardaki sürüm numarası (örn: models/v1.5.0/model.bin)
+	SelectByTimestamp    = "timestamp"     // Anahtardaki zaman damgası (örn: models/20240115T103000Z/model.bin)
+	SelectByLastModified = "last_modified" // S3'ün LastModified değeri
+)
+
+// resolveRemote, deploy edilmesi gereken uzak revizyonu bulur.
+// Tek anahtar modunda doğrudan 's3_key' sorgulanır. 's3_prefix' ayarlıysa
+// prefix altındaki nesneler listelenir ve en yeni sürüm seçilir.
+func (p *Poller) resolveRemote() (ObjectVersion, error) {
+	if p.cfg.S3Prefix == "" {
+		// 's3_version_id' ayarlıysa en güncel revizyon yerine o revizyona sabitlenir.
+		return p.s3.HeadObject(p.cfg.S3Bucket, p.cfg.S3Key, p.cfg.S3VersionID)
+	}
+
+	// Prefix altında modelle birlikte yan dosyalar (tokenizer.json, etiketler,
+	// özetler...) da sürüm numarası taşır; hangisinin model olduğunu desen belirler.
+	if p.cfg.S3KeyPattern == "" {
+		return ObjectVersion{}, fmt.Errorf("prefix izleme modunda 's3_key_pattern' zorunludur")
+	}
+	pattern, err := regexp.Compile(p.cfg.S3KeyPattern)
+	if err != nil {
+		return ObjectVersion{}, fmt.Errorf("s3_key_pattern geçersiz: %w", err)
+	}
+
+	objects, err := p.s3.ListObjects(p.cfg.S3Bucket, p.cfg.S3Prefix)
+	if err != nil {
+		return ObjectVersion{}, err
+	}
+	latest, err := selectLatest(objects, p.cfg.S3SelectBy, pattern)
+	if err != nil {
+		return ObjectVersion{}, fmt.Errorf("'%s' altında uygun bir model bulunamadı: %w", p.cfg.S3Prefix, err)
+	}
+
+	// Listeleme VersionID ve metadata döndürmez; seçilen nesnenin ayrıntıları
+	// HeadObject ile alınır.
+	return p.s3.HeadObject(p.cfg.S3Bucket, latest.Key, "")
+}
+
+// releaseVersion, bir anahtardan çıkarılan sıralanabilir sürüm bilgisidir.
+type releaseVersion struct {
+	obj     ObjectVersion
+	semver  semver
+	instant time.Time
+}
+
+// selectLatest, listelenen nesneler arasından 'selectBy' stratejisine göre en
+// yenisini seçer. 'pattern' verilmişse ona uymayan anahtarlar atlanır; pattern bir
+// yakalama grubu içeriyorsa sürüm bilgisi sadece o gruptan okunur.
+// İmza, özet ve delta yan dosyaları (.sig, .sha256, .delta) her zaman atlanır.
+func selectLatest(objects []ObjectVersion, selectBy string, pattern *regexp.Regexp) (ObjectVersion, error) {
+	if selectBy == "" {
+		selectBy = SelectBySemver
+	}
+	if selectBy != SelectBySemver && selectBy != SelectByTimestamp && selectBy != SelectByLastModified {
+		return ObjectVersion{}, fmt.Errorf("bilinmeyen s3_select_by değeri: '%s'", selectBy)
+	}
+
+	var best *releaseVersion
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") || strings.HasSuffix(obj.Key, ".sig") || strings.HasSuffix(obj.Key, ".sha256") || strings.HasSuffix(obj.Key, deltaSuffix) {
+			continue
+		}
+		token := obj.Key
+		if pattern != nil {
+			m := pattern.FindStringSubmatch(obj.Key)
+			if m == nil {
+				continue
+			}
+			if len(m) > 1 {
+				token = m[1]
+			}
+		}
+
+		candidate := releaseVersion{obj: obj}
+		switch selectBy {
+		case SelectBySemver:
+			v, ok := parseSemver(token)
+			if !ok {
+				log.Printf("[Prefix] '%s' anahtarında sürüm numarası yok, atlanıyor.", obj.Key)
+				continue
+			}
+			candidate.semver = v
+		case SelectByTimestamp:
+			t, ok := parseTimestamp(token)
+			if !ok {
+				log.Printf("[Prefix] '%s' anahtarında zaman damgası yok, atlanıyor.", obj.Key)
+				continue
+			}
+			candidate.instant = t
+		case SelectByLastModified:
+			candidate.instant = obj.LastModified
+		}
+
+		if best == nil || candidate.newerThan(best, selectBy) {
+			best = &candidate
+		}
+	}
+
+	if best == nil {
+		return ObjectVersion{}, ErrObjectNotFound
+	}
+	return best.obj, nil
+}
+
+// newerThan, 'r'nin 'other'dan daha yeni bir sürüm olup olmadığını döndürür.
+// Sürümler eşitse daha sonra yüklenen (LastModified) nesne tercih edilir.
+func (r *releaseVersion) newerThan(other *releaseVersion, selectBy string) bool {
+	cmp := 0
+	switch selectBy {
+	case SelectBySemver:
+		cmp = r.semver.compare(other.semver)
+	default:
+		cmp = r.instant.Compare(other.instant)
+	}
+	if cmp != 0 {
+		return cmp > 0
+	}
+	return r.obj.LastModified.After(other.obj.LastModified)
+}
+
+// semver, "MAJOR.MINOR.PATCH[-PRERELEASE]" biçimindeki bir sürüm numarasıdır.
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+var semverPattern = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?`)
+
+// parseSemver, 's' içindeki ilk semver sürüm numarasını bulur.
+// (örn: "models/v1.5.0/model.bin" -> 1.5.0, "model-2.0.0-rc.1" -> 2.0.0-rc.1)
+func parseSemver(s string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(s)
+	if m == nil {
+		return semver{}, false
+	}
+	var v semver
+	var err error
+	if v.major, err = strconv.Atoi(m[1]); err != nil {
+		return semver{}, false
+	}
+	if v.minor, err = strconv.Atoi(m[2]); err != nil {
+		return semver{}, false
+	}
+	if v.patch, err = strconv.Atoi(m[3]); err != nil {
+		return semver{}, false
+	}
+	v.prerelease = m[4]
+	return v, true
+}
+
+// compare, semver kurallarına göre v < o ise -1, eşitse 0, v > o ise 1 döndürür.
+// Ön sürümler (örn: 2.0.0-rc.1) aynı numaralı asıl sürümden daha eskidir.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.prerelease, "."), strings.Split(o.prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseField(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// comparePrereleaseField, ön sürümün tek bir alanını karşılaştırır: sayısal
+// alanlar sayı olarak, diğerleri metin olarak karşılaştırılır; sayısal alanlar
+// her zaman metin alanlardan önce gelir.
+func comparePrereleaseField(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+var timestampPattern = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})(?:[T_-]?(\d{2}):?(\d{2}):?(\d{2}))?`)
+
+// parseTimestamp, 's' içindeki ilk zaman damgasını UTC olarak okur.
+// Desteklenen biçimler: 20240115, 2024-01-15, 20240115T103000Z,
+// 2024-01-15T10:30:00Z, 20240115-103000.
+func parseTimestamp(s string) (time.Time, bool) {
+	m := timestampPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	hh, mm, ss := "00", "00", "00"
+	if m[4] != "" {
+		hh, mm, ss = m[4], m[5], m[6]
+	}
+	t, err := time.Parse("20060102150405", m[1]+m[2]+m[3]+hh+mm+ss)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.4.0", "1.5.0", -1},
+		{"v1.10.0", "v1.9.9", 1},
+		{"2.0.0", "2.0.0", 0},
+		{"2.0.0-rc.1", "2.0.0", -1},
+		{"2.0.0-rc.2", "2.0.0-rc.10", -1},
+		{"2.0.0-alpha", "2.0.0-alpha.1", -1},
+		{"2.0.0-1", "2.0.0-alpha", -1},
+	}
+	for _, tt := range tests {
+		a, okA := parseSemver(tt.a)
+		b, okB := parseSemver(tt.b)
+		if !okA || !okB {
+			t.Fatalf("%q veya %q ayrıştırılamadı", tt.a, tt.b)
+		}
+		if got := a.compare(b); got != tt.want {
+			t.Errorf("compare(%q, %q) = %d, beklenen %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for _, s := range []string{"models/20240115T103000Z/model.bin", "models/2024-01-15T10:30:00Z/model.bin", "model-20240115-103000.bin"} {
+		got, ok := parseTimestamp(s)
+		if !ok || !got.Equal(want) {
+			t.Errorf("parseTimestamp(%q) = %v, %v; beklenen %v", s, got, ok, want)
+		}
+	}
+	if _, ok := parseTimestamp("models/latest/model.bin"); ok {
+		t.Error("Zaman damgası olmayan anahtar ayrıştırılmamalıydı")
+	}
+}
+
+func TestSelectLatest(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	objects := []ObjectVersion{
+		{Key: "models/v1.5.0/model.bin", LastModified: base},
+		{Key: "models/v1.4.0/model.bin", LastModified: base.Add(time.Hour)},
+		{Key: "models/v1.6.0-rc.1/model.bin", LastModified: base.Add(2 * time.Hour)},
+		{Key: "models/v1.6.0-rc.1/model.bin.sig", LastModified: base.Add(3 * time.Hour)},
+		{Key: "models/README.txt", LastModified: base.Add(4 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		selectBy string
+		pattern  string
+		want     string
+	}{
+		{"semver", SelectBySemver, "", "models/v1.6.0-rc.1/model.bin"},
+		{"pattern ile ön sürümler hariç", SelectBySemver, `^models/(v\d+\.\d+\.\d+)/model\.bin$`, "models/v1.5.0/model.bin"},
+		{"last_modified", SelectByLastModified, `model\.bin$`, "models/v1.6.0-rc.1/model.bin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
+			got, err := selectLatest(objects, tt.selectBy, pattern)
+			if err != nil {
+				t.Fatalf("selectLatest() hata döndürdü: %v", err)
+			}
+			if got.Key != tt.want {
+				t.Errorf("Seçilen anahtar '%s', beklenen '%s'", got.Key, tt.want)
+			}
+		})
+	}
+
+	if _, err := selectLatest(nil, SelectBySemver, nil); err == nil {
+		t.Error("Boş listede hata dönmeliydi")
+	}
+}
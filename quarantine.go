@@ -0,0 +1,71 @@
+package main
+
+import (
+	"time"
+)
+
+// QuarantineEntry, başarısız olmuş bir model revizyonunun karantina kaydıdır.
+type QuarantineEntry struct {
+	Reason        string    `json:"reason"`          // Son başarısızlığın sebebi
+	FirstFailedAt time.Time `json:"first_failed_at"` // İlk başarısızlık zamanı
+	LastFailedAt  time.Time `json:"last_failed_at"`  // Son başarısızlık zamanı
+	Failures      int       `json:"failures"`        // Toplam başarısızlık sayısı
+}
+
+// quarantineThreshold, bir revizyonun kaç başarısızlıktan sonra atlanacağını döndürür.
+func (p *Poller) quarantineThreshold() int {
+	if p.cfg.QuarantineThreshold > 0 {
+		return p.cfg.QuarantineThreshold
+	}
+	return 1
+}
+
+// quarantine, 'version' (VersionID veya ETag) için bir başarısızlık kaydeder ve
+// durumu diske yazar. Başarısızlık sayısı eşiğe ulaşınca bu revizyon, yeni bir
+// revizyon gelene veya operatör karantinayı temizleyene kadar tekrar denenmez.
+func (p *Poller) quarantine(version string, reason error) {
+	now := time.Now()
+	p.mu.Lock()
+	entry, ok := p.quarantined[version]
+	if !ok {
+		entry = QuarantineEntry{FirstFailedAt: now}
+	}
+	entry.Reason = reason.Error()
+	entry.LastFailedAt = now
+	entry.Failures++
+	p.quarantined[version] = entry
+	p.mu.Unlock()
+
+	if entry.Failures >= p.quarantineThreshold() {
+		p.log.Printf("[Quarantine] Revizyon '%s' karantinaya alındı (%d başarısızlık). Sebep: %v", version, entry.Failures, reason)
+	} else {
+		p.log.Printf("[Quarantine] Revizyon '%s' başarısız oldu (%d/%d).", version, entry.Failures, p.quarantineThreshold())
+	}
+	if err := p.saveState(); err != nil {
+		p.log.Printf("[Quarantine] UYARI: Karantina kaydedilemedi: %v", err)
+	}
+}
+
+// isQuarantined, 'version'ın karantinada olup olmadığını döndürür.
+func (p *Poller) isQuarantined(version string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	entry, ok := p.quarantined[version]
+	return ok && entry.Failures >= p.quarantineThreshold()
+}
+
+// ClearQuarantine, operatörün karantinayı elle temizlemesi içindir.
+// 'version' boşsa tüm karantina temizlenir. Temizlenen revizyon bir sonraki
+// döngüde tekrar denenir.
+func (p *Poller) ClearQuarantine(version string) error {
+	p.mu.Lock()
+	if version == "" {
+		p.quarantined = make(map[string]QuarantineEntry)
+	} else {
+		delete(p.quarantined, version)
+	}
+	p.mu.Unlock()
+
+	p.log.Printf("[Quarantine] Karantina temizlendi (revizyon: '%s').", version)
+	return p.saveState()
+}
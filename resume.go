@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// errStalePartial, yarım indirmenin ait olduğu ETag'in kaynakta artık geçerli
+// olmadığını belirtir (örn: S3 "412 Precondition Failed"). Bu durumda yarım
+// dosya atılır ve indirme baştan başlar.
+var errStalePartial = errors.New("yarım indirme eski bir versiyona ait")
+
+// partialCheckpointSize, yarım dosyanın kaç baytta bir fsync edilip yan
+// dosyaya işleneceğidir. Bağlantı koptuğunda en fazla bu kadar veri tekrar indirilir.
+const partialCheckpointSize = 8 << 20
+
+// byteRange, dosyanın [Start, End) aralığındaki baytlarını belirtir.
+type byteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// partialDownload, staging'deki yarım bir dosyanın yan (sidecar) kaydıdır.
+// '<dosya>.part.json' olarak saklanır.
+type partialDownload struct {
+	ETag   string      `json:"etag"`
+	Size   int64       `json:"size"`   // Nesnenin toplam boyutu (bilinmiyorsa 0)
+	Ranges []byteRange `json:"ranges"` // Diske yazılmış ve fsync edilmiş aralıklar
+}
+
+// received, dosyanın başından itibaren kesintisiz olarak alınmış bayt sayısıdır.
+// İndirme sıralı olduğu için devam noktası budur.
+func (pd *partialDownload) received() int64 {
+	for _, r := range pd.Ranges {
+		if r.Start == 0 {
+			return r.End
+		}
+	}
+	return 0
+}
+
+// rangeFetcher, kaynaktan 'offset' baytından itibaren (dosyanın sonuna kadar)
+// veri akışı açar. 'ifMatch' boş değilse, kaynak sadece bu ETag hâlâ
+// geçerliyse veri döndürmeli; değilse errStalePartial dönmelidir.
+// Dönen 'etag' nesnenin güncel ETag'i, 'total' ise toplam boyutudur (bilinmiyorsa 0).
+type rangeFetcher func(offset int64, ifMatch string) (body io.ReadCloser, etag string, total int64, err error)
+
+// stageResumable, dosyayı önce staging dizinindeki '.part' dosyasına indirir,
+// SHA-256 özetini yazma sırasında hesaplar ve ancak tamamlanıp doğrulandığında
+// atomik olarak 'dest' yoluna taşır; böylece yarım veya özeti eşleşmeyen bir
+// dosya asla son adında görünmez. Bağlantı koparsa yarım dosyayı ve yan kaydını saklar.
+// Bir sonraki denemede ETag değişmemişse indirme kaldığı yerden devam eder.
+func stageResumable(stagingDir, dest, expectedSHA256 string, fetch rangeFetcher) (string, error) {
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return "", fmt.Errorf("staging dizini oluşturulamadı (%s): %w", stagingDir, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", fmt.Errorf("hedef dizin oluşturulamadı (%s): %w", filepath.Dir(dest), err)
+	}
+
+	partPath := filepath.Join(stagingDir, filepath.Base(dest)+".part")
+	metaPath := partPath + ".json"
+
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("indirme hedefi oluşturulamadı (%s): %w", partPath, err)
+	}
+	defer file.Close()
+
+	// 1. Önceki denemeden kalan yarım indirmeyi yükle ve özetini yeniden hesapla.
+	meta := loadPartial(metaPath)
+	hasher := sha256.New()
+	offset := meta.received()
+	if offset > 0 {
+		if _, err := io.CopyN(hasher, io.NewSectionReader(file, 0, offset), offset); err != nil {
+			// Yarım dosya, kaydın söylediğinden kısa; baştan başla.
+			meta, offset, hasher = &partialDownload{}, 0, sha256.New()
+		}
+	}
+
+	// 2. Eksik kısmı indir (ETag değiştiyse yarım dosyayı atıp baştan başla).
+	if meta.Size == 0 || offset < meta.Size {
+		if offset > 0 {
+			log.Printf("[Download] Yarım indirme bulundu, %d. bayttan devam ediliyor. (ETag: '%s')", offset, meta.ETag)
+		}
+		body, etag, total, err := fetch(offset, meta.ETag)
+		if errors.Is(err, errStalePartial) {
+			log.Printf("[Download] Kaynak değişmiş (eski ETag: '%s'), indirme baştan başlıyor.", meta.ETag)
+			meta, offset, hasher = &partialDownload{}, 0, sha256.New()
+			body, etag, total, err = fetch(0, "")
+		}
+		if err != nil {
+			return "", err
+		}
+		meta.ETag, meta.Size = etag, total
+
+		if err := file.Truncate(offset); err != nil {
+			body.Close()
+			return "", fmt.Errorf("yarım dosya kesilemedi: %w", err)
+		}
+		cw := &checkpointWriter{file: file, hasher: hasher, meta: meta, metaPath: metaPath, offset: offset}
+		_, err = io.Copy(cw, body)
+		body.Close()
+		if err == nil {
+			err = cw.checkpoint()
+		}
+		if err != nil {
+			// Yarım dosya ve kaydı silinmez; bir sonraki deneme kaldığı yerden devam eder.
+			cw.checkpoint()
+			return "", fmt.Errorf("indirme yarıda kaldı (%d bayt alındı, devam edilebilir): %w", cw.offset, err)
+		}
+		offset = cw.offset
+	}
+	if meta.Size > 0 && offset != meta.Size {
+		return "", fmt.Errorf("indirme eksik: %d/%d bayt", offset, meta.Size)
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	// 3. Özeti doğrula ve dosyayı atomik olarak hedefe taşı.
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	if err := verifySHA256(expectedSHA256, digest); err != nil {
+		os.Remove(partPath)
+		os.Remove(metaPath)
+		return "", err
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return "", fmt.Errorf("indirilen dosya yerine taşınamadı (%s): %w", dest, err)
+	}
+	os.Remove(metaPath)
+	syncDir(filepath.Dir(dest))
+	return digest, nil
+}
+
+// loadPartial, yarım indirme kaydını okur. Kayıt yoksa veya bozuksa boş kayıt döner.
+func loadPartial(metaPath string) *partialDownload {
+	var meta partialDownload
+	data, err := os.ReadFile(metaPath)
+	if err != nil || json.Unmarshal(data, &meta) != nil {
+		return &partialDownload{}
+	}
+	return &meta
+}
+
+// checkpointWriter, gelen veriyi yarım dosyaya yazar, özetini hesaplar ve
+// her 'partialCheckpointSize' baytta bir dosyayı fsync edip yan kaydı günceller.
+type checkpointWriter struct {
+	file        *os.File
+	hasher      hash.Hash
+	meta        *partialDownload
+	metaPath    string
+	offset      int64
+	unsyncBytes int64
+}
+
+// Write, io.Writer arayüzünü uygular.
+func (cw *checkpointWriter) Write(p []byte) (int, error) {
+	n, err := cw.file.WriteAt(p, cw.offset)
+	cw.hasher.Write(p[:n])
+	cw.offset += int64(n)
+	cw.unsyncBytes += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if cw.unsyncBytes >= partialCheckpointSize {
+		if err := cw.checkpoint(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// checkpoint, o ana kadar yazılan veriyi fsync eder ve alınan aralığı yan kayda işler.
+// Kayıt, veri diske yazıldıktan sonra güncellenir; böylece kayıt asla diskte
+// olmayan bir baytı "alındı" olarak göstermez.
+func (cw *checkpointWriter) checkpoint() error {
+	if err := cw.file.Sync(); err != nil {
+		return fmt.Errorf("yarım dosya fsync edilemedi: %w", err)
+	}
+	cw.unsyncBytes = 0
+	cw.meta.Ranges = []byteRange{{Start: 0, End: cw.offset}}
+	data, err := json.Marshal(cw.meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cw.metaPath, data)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// flakyReader, belirli bir bayttan sonra bağlantı kopmuş gibi hata döndürür.
+type flakyReader struct {
+	r         io.Reader
+	remaining int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if f.remaining <= 0 {
+		return 0, errors.New("bağlantı koptu")
+	}
+	if len(p) > f.remaining {
+		p = p[:f.remaining]
+	}
+	n, err := f.r.Read(p)
+	f.remaining -= n
+	return n, err
+}
+
+// TestStageResumable_ResumesAfterFailure, yarıda kalan bir indirmenin
+// ikinci denemede kaldığı yerden devam ettiğini ve özetin doğru olduğunu test eder.
+func TestStageResumable_ResumesAfterFailure(t *testing.T) {
+	root := t.TempDir()
+	stagingDir := filepath.Join(root, stagingSubdir)
+	dest := filepath.Join(root, modelsSubdir, "model-v1.bin")
+
+	content := bytes.Repeat([]byte("edgesync-model-"), 1000)
+	sum := sha256.Sum256(content)
+	expected := hex.EncodeToString(sum[:])
+
+	var offsets []int64
+	fetch := func(failAfter int) rangeFetcher {
+		return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+			offsets = append(offsets, offset)
+			var r io.Reader = bytes.NewReader(content[offset:])
+			if failAfter > 0 {
+				r = &flakyReader{r: r, remaining: failAfter}
+			}
+			return io.NopCloser(r), `"v1"`, int64(len(content)), nil
+		}
+	}
+
+	// 1. Deneme: 4000 bayttan sonra bağlantı kopar.
+	if _, err := stageResumable(stagingDir, dest, expected, fetch(4000)); err == nil {
+		t.Fatal("İlk deneme hata döndürmeliydi")
+	}
+	if _, err := os.Stat(filepath.Join(stagingDir, "model-v1.bin.part.json")); err != nil {
+		t.Fatalf("Yarım indirme kaydı saklanmalıydı: %v", err)
+	}
+
+	// 2. Deneme: kaldığı yerden devam eder.
+	digest, err := stageResumable(stagingDir, dest, expected, fetch(0))
+	if err != nil {
+		t.Fatalf("İkinci deneme başarılı olmalıydı: %v", err)
+	}
+	if digest != expected {
+		t.Errorf("Özet hatalı: %s", digest)
+	}
+	if len(offsets) != 2 || offsets[1] != 4000 {
+		t.Errorf("İkinci istek 4000. bayttan başlamalıydı, istekler: %v", offsets)
+	}
+	data, _ := os.ReadFile(dest)
+	if !bytes.Equal(data, content) {
+		t.Errorf("Hedef dosya içeriği kaynakla aynı değil (%d/%d bayt)", len(data), len(content))
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 0 {
+		t.Errorf("Tamamlanan indirmeden sonra staging boş olmalıydı, %d girdi var", len(entries))
+	}
+}
+
+// TestStageResumable_RestartsWhenETagChanged, kaynak değiştiyse yarım dosyanın
+// atılıp indirmenin baştan başladığını test eder.
+func TestStageResumable_RestartsWhenETagChanged(t *testing.T) {
+	root := t.TempDir()
+	stagingDir := filepath.Join(root, stagingSubdir)
+	dest := filepath.Join(root, modelsSubdir, "model.bin")
+	os.MkdirAll(stagingDir, 0o755)
+	os.WriteFile(filepath.Join(stagingDir, "model.bin.part"), []byte("eski"), 0o644)
+	os.WriteFile(filepath.Join(stagingDir, "model.bin.part.json"), []byte(`{"etag":"\"old\"","size":8,"ranges":[{"start":0,"end":4}]}`), 0o644)
+
+	var calls []string
+	fetch := func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		calls = append(calls, ifMatch)
+		if ifMatch == `"old"` {
+			return nil, "", 0, errStalePartial
+		}
+		return io.NopCloser(bytes.NewReader([]byte("yeni model"))), `"new"`, 10, nil
+	}
+
+	if _, err := stageResumable(stagingDir, dest, "", fetch); err != nil {
+		t.Fatalf("stageResumable() hata döndürdü: %v", err)
+	}
+	if len(calls) != 2 || calls[1] != "" {
+		t.Errorf("Önce eski ETag ile, sonra koşulsuz istek yapılmalıydı: %q", calls)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "yeni model" {
+		t.Errorf("Hedef dosya içeriği hatalı: '%s'", data)
+	}
+}
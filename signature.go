@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrSignatureInvalid, model imzası yapılandırılmış hiçbir açık anahtarla
+// doğrulanamadığında döndürülür.
+var ErrSignatureInvalid = errors.New("model imzası geçersiz")
+
+// ErrSignatureDowngrade, geçerli imzalı bir modelin sürüm numarası aktif
+// modelinkinden eski olduğunda döndürülür.
+var ErrSignatureDowngrade = errors.New("imzalı sürüm aktif modelden eski")
+
+// modelSignature, '<key>.sig' dosyasından okunan imza ve imzalanan sürüm numarasıdır.
+type modelSignature struct {
+	sig     []byte
+	version uint64 // Yayıncının her yayında artırdığı sürüm numarası (derleme numarası, zaman damgası...)
+}
+
+// parseSignature, '<key>.sig' dosyasının içeriğinden ed25519 imzasını okur.
+// Biçim minisign'a benzer: "untrusted comment:" satırları yok sayılır,
+// "trusted comment: version=<n>" satırı imzalanan sürüm numarasıdır, ilk diğer
+// satır base64 kodlu 64 baytlık imzadır.
+func parseSignature(content []byte) (*modelSignature, error) {
+	var parsed modelSignature
+	hasVersion := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		if comment, ok := strings.CutPrefix(line, "trusted comment:"); ok {
+			value, ok := strings.CutPrefix(strings.TrimSpace(comment), "version=")
+			if !ok {
+				return nil, fmt.Errorf("imzadaki 'trusted comment' satırı 'version=<n>' biçiminde değil: '%s'", comment)
+			}
+			version, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("imzalı sürüm numarası okunamadı ('%s'): %w", value, err)
+			}
+			parsed.version, hasVersion = version, true
+			continue
+		}
+		if parsed.sig != nil {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("imza base64 olarak çözülemedi: %w", err)
+		}
+		if len(sig) != ed25519.SignatureSize {
+			return nil, fmt.Errorf("imza uzunluğu %d bayt, %d bekleniyordu", len(sig), ed25519.SignatureSize)
+		}
+		parsed.sig = sig
+	}
+	if parsed.sig == nil {
+		return nil, fmt.Errorf("imza dosyasında imza bulunamadı")
+	}
+	if !hasVersion {
+		return nil, fmt.Errorf("imza dosyasında sürüm numarası ('trusted comment: version=<n>') bulunamadı")
+	}
+	return &parsed, nil
+}
+
+// signedMessage, imzalanan mesajı oluşturur. Özetle birlikte sürüm numarası da
+// imzalandığı için eski bir model, kendi (geçerli) imzasıyla yeniden
+// yüklenerek cihaza geri getirilemez.
+func signedMessage(digestHex string, version uint64) []byte {
+	return []byte(fmt.Sprintf("edgesync-model\nsha256=%s\nversion=%d\n", strings.ToLower(digestHex), version))
+}
+
+// parsePublicKeys, yapılandırmadaki base64 kodlu ed25519 açık anahtarlarını çözer.
+func parsePublicKeys(encoded []string) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for i, e := range encoded {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(e))
+		if err != nil {
+			return nil, fmt.Errorf("%d. açık anahtar base64 olarak çözülemedi: %w", i+1, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%d. açık anahtar %d bayt, %d bekleniyordu", i+1, len(raw), ed25519.PublicKeySize)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, nil
+}
+
+// verifyModelSignature, imzanın modelin SHA-256 özeti ve imzalı sürüm numarası
+// (bkz. signedMessage) üzerinde anahtarlardan herhangi biriyle atılmış olduğunu
+// doğrular. Dosyanın kendisi yerine özetinin imzalanması, çok büyük modellerin
+// belleğe alınmasını önler.
+func verifyModelSignature(digestHex string, sig *modelSignature, keys []ed25519.PublicKey) error {
+	if _, err := hex.DecodeString(digestHex); err != nil {
+		return fmt.Errorf("model özeti çözülemedi: %w", err)
+	}
+	message := signedMessage(digestHex, sig.version)
+	for _, key := range keys {
+		if ed25519.Verify(key, message, sig.sig) {
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
+
+// fetchSignature, 'key' anahtarındaki modelin ayrık (detached) imzasını
+// '<key>.sig' nesnesinden okur. İmza doğrulaması yapılandırılmamışsa nil döner.
+func (p *Poller) fetchSignature(key string) (*modelSignature, error) {
+	if len(p.cfg.SigningPublicKeys) == 0 {
+		return nil, nil
+	}
+
+	sigKey := key + ".sig"
+	content, err := p.s3.FetchObject(p.cfg.S3Bucket, sigKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, fmt.Errorf("model imzası bulunamadı ('%s'). İmza doğrulaması açıkken imzasız model deploy edilmez", sigKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("model imzası okunamadı ('%s'): %w", sigKey, err)
+	}
+	return parseSignature(content)
+}
+
+// verifySignature, indirilen modelin özetini daha önce okunan imzayla doğrular.
+// İmzalı sürüm numarası aktif modelinkinden küçükse (veya aynı numarayla farklı
+// bir model yayınlanmışsa) imza geçerli olsa da model reddedilir.
+func (p *Poller) verifySignature(digestHex string, sig *modelSignature) error {
+	if len(p.cfg.SigningPublicKeys) == 0 {
+		return nil
+	}
+	keys, err := parsePublicKeys(p.cfg.SigningPublicKeys)
+	if err != nil {
+		return fmt.Errorf("signing_public_keys hatalı: %w", err)
+	}
+	if err := verifyModelSignature(digestHex, sig, keys); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	deployedVersion, deployedSHA256 := p.deployedSignedVersion, p.deployedSHA256
+	p.mu.RUnlock()
+	if sig.version < deployedVersion || (deployedVersion > 0 && sig.version == deployedVersion && !strings.EqualFold(digestHex, deployedSHA256)) {
+		return fmt.Errorf("%w: imzalı sürüm %d, aktif model %d", ErrSignatureDowngrade, sig.version, deployedVersion)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startSoak, sağlık kontrollerini geçen yeni modeli izleme penceresine alır.
+// İzleme yapılandırılmamışsa dağıtım doğrudan commit edilir.
+func (p *Poller) startSoak(entry *JournalEntry) error {
+	if p.cfg.Soak.DurationSeconds <= 0 {
+		return p.commit(entry)
+	}
+
+	entry.SoakUntil = time.Now().Add(time.Duration(p.cfg.Soak.DurationSeconds) * time.Second)
+	if err := p.writeJournal(entry, PhaseSoaking); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.soak = entry
+	p.mu.Unlock()
+	p.log.Printf("[Soak] Yeni model (revizyon: '%s') %s tarihine kadar izlenecek.", entry.ID(), entry.SoakUntil.Format(time.RFC3339))
+	return nil
+}
+
+// NextPollInterval, bir sonraki kontrole kadar beklenecek süreyi döndürür.
+// Model izleme penceresindeyken, bildirimler açık olsa bile düzenli kontrol
+// aralığı kullanılır; izleme kontrolleri bir bildirimle tetiklenmez.
+func (p *Poller) NextPollInterval() time.Duration {
+	p.mu.RLock()
+	soaking := p.soak != nil
+	p.mu.RUnlock()
+	if soaking {
+		return min(p.cfg.PollInterval(), p.cfg.regularPollInterval())
+	}
+	return p.cfg.PollInterval()
+}
+
+// checkSoak, izleme penceresindeki modeli kontrol eder. Sağlık kontrolleri
+// veya hata oranı bozulursa eski modele döner; pencere sorunsuz dolarsa
+// dağıtımı commit eder ve model "stabil" olur.
+func (p *Poller) checkSoak() error {
+	p.mu.RLock()
+	entry, err := p.soak, p.soakFailure
+	p.mu.RUnlock()
+
+	// Önceki döngüde geri alınamayan bir model tekrar kontrol edilmez; geri alma yeniden denenir.
+	if err == nil {
+		err = p.checkHealth()
+	}
+	if err == nil {
+		err = p.checkErrorRate()
+	}
+	if err != nil {
+		return p.failSoak(entry, err)
+	}
+
+	remaining := time.Until(entry.SoakUntil)
+	if remaining > 0 {
+		p.log.Printf("[Soak] Model sağlıklı. İzlemenin bitmesine %v kaldı.", remaining.Round(time.Second))
+		return nil
+	}
+
+	p.log.Printf("[Soak] İzleme penceresi sorunsuz tamamlandı. Model (revizyon: '%s') stabil.", entry.ID())
+	if err := p.commit(entry); err != nil {
+		return err
+	}
+	p.endSoak()
+	return nil
+}
+
+// failSoak, izleme sırasında bozulan modeli geri alır. Eski model bilinmiyorsa
+// servis `deploy.sh --stop` ile durdurulur. İzleme durumu (bellekte ve günlükte)
+// ancak bu adımlar başarılı olursa kapatılır; başarısız olursa bir sonraki döngü
+// sağlık kontrolü yapmadan tekrar dener.
+func (p *Poller) failSoak(entry *JournalEntry, cause error) error {
+	p.mu.Lock()
+	first := p.soakFailure == nil
+	p.soakFailure = cause
+	p.mu.Unlock()
+	if first {
+		p.log.Printf("[Soak] HATA! İzleme sırasında model bozuldu: %v", cause)
+		p.quarantine(entry.ID(), cause)
+	}
+
+	if entry.PreviousTarget == "" {
+		p.log.Println("[Soak] Dönülecek eski model yok. Servis durduruluyor... (`deploy.sh --stop`)")
+		if err := p.deploy.Run(p.cfg.DeployScriptPath, "--stop"); err != nil {
+			return fmt.Errorf("KRİTİK HATA! İzleme hatası sonrası dönülecek model yok ve servis durdurulamadı: %w (izleme hatası: %v)", err, cause)
+		}
+		p.clearJournal()
+		p.endSoak()
+		return fmt.Errorf("izleme hatası, dönülecek model olmadığı için servis durduruldu: %w", cause)
+	}
+
+	if err := p.rollback(entry.PreviousTarget); err != nil {
+		return err
+	}
+	p.endSoak()
+	return fmt.Errorf("izleme hatası (rollback yapıldı): %w", cause)
+}
+
+// endSoak, izleme penceresini bellekte kapatır.
+func (p *Poller) endSoak() {
+	p.mu.Lock()
+	p.soak, p.soakFailure = nil, nil
+	p.mu.Unlock()
+}
+
+// checkErrorRate, deploy script'inin bildirdiği hata oranını okur
+// (`deploy.sh --error-rate`) ve yapılandırılan sınırı aşıp aşmadığını kontrol eder.
+// Script, çıktısının son satırına 0.0 - 1.0 arası bir sayı yazmalıdır.
+func (p *Poller) checkErrorRate() error {
+	maxRate := p.cfg.Soak.MaxErrorRate
+	if maxRate <= 0 {
+		return nil // Hata oranı izlenmiyor.
+	}
+
+	output, err := p.deploy.Output(p.cfg.DeployScriptPath, "--error-rate")
+	if err != nil {
+		return fmt.Errorf("hata oranı okunamadı: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	rate, err := strconv.ParseFloat(strings.TrimSpace(lines[len(lines)-1]), 64)
+	if err != nil {
+		return fmt.Errorf("hata oranı çıktısı anlaşılamadı ('%s'): %w", strings.TrimSpace(output), err)
+	}
+	if rate > maxRate {
+		return fmt.Errorf("hata oranı %.4f, izin verilen en yüksek değer %.4f", rate, maxRate)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Veri kök dizini (data root) altındaki alt dizinler.
+// Hepsi aynı dosya sisteminde olduğu için staging -> models taşıması atomik bir rename'dir.
+const (
+	stagingSubdir = "staging" // İndirilmekte olan (yarım) dosyalar
+	modelsSubdir  = "models"  // Tamamlanmış ve doğrulanmış modeller
+	stateSubdir   = "state"   // Kalıcı durum ve dağıtım günlüğü
+)
+
+// partialMaxAge, devam ettirilebilir yarım bir indirmenin staging'de en fazla
+// ne kadar saklanacağıdır. Daha eski yarım dosyalar başlangıçta silinir.
+const partialMaxAge = 7 * 24 * time.Hour
+
+// CleanStaging, önceki çalışmadan kalmış yarım dosyaları siler.
+// Devam ettirilebilir indirmeler ('.part' + '.part.json' kaydı) yeterince
+// yeniyse korunur; geri kalan her şey silinir.
+// Ajan başlarken, hiçbir indirme devam etmiyorken çağrılmalıdır.
+func CleanStaging(stagingDir string) error {
+	entries, err := os.ReadDir(stagingDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("staging dizini okunamadı: %w", err)
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(stagingDir, e.Name())
+		if isResumablePartial(path) || isResumablePartial(strings.TrimSuffix(path, ".json")) {
+			log.Printf("[Staging] Devam ettirilebilir yarım indirme korunuyor: %s", path)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("yarım dosya silinemedi (%s): %w", path, err)
+		}
+		log.Printf("[Staging] Önceki çalışmadan kalan yarım dosya silindi: %s", path)
+	}
+	return nil
+}
+
+// isResumablePartial, 'partPath'in geçerli bir yan kaydı olan ve
+// 'partialMaxAge'den yeni bir yarım indirme olup olmadığını döndürür.
+func isResumablePartial(partPath string) bool {
+	if !strings.HasSuffix(partPath, ".part") {
+		return false
+	}
+	info, err := os.Stat(partPath)
+	if err != nil || time.Since(info.ModTime()) > partialMaxAge {
+		return false
+	}
+	return loadPartial(partPath+".json").ETag != ""
+}
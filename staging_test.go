@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// staticFetcher, 'content' içeriğini (istenen konumdan itibaren) döndüren bir rangeFetcher'dır.
+func staticFetcher(content []byte) rangeFetcher {
+	return func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		return io.NopCloser(bytes.NewReader(content[offset:])), "v1", int64(len(content)), nil
+	}
+}
+
+// TestStageResumable_Staging, başarılı bir indirmenin hedefe taşındığını ve
+// staging'de iz bırakmadığını, başarısız bir indirmenin ise hedefte
+// görünmediğini test eder.
+func TestStageResumable_Staging(t *testing.T) {
+	root := t.TempDir()
+	stagingDir := filepath.Join(root, stagingSubdir)
+	dest := filepath.Join(root, modelsSubdir, "model-v1.bin")
+
+	// Başarısız indirme.
+	_, err := stageResumable(stagingDir, dest, "", func(offset int64, ifMatch string) (io.ReadCloser, string, int64, error) {
+		return nil, "", 0, errors.New("bağlantı koptu")
+	})
+	if err == nil {
+		t.Fatal("stageResumable() indirme hatasını döndürmeliydi")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Başarısız indirmeden sonra hedef dosya oluşmamalıydı")
+	}
+
+	// Başarılı indirme.
+	content := []byte("model verisi")
+	sum := sha256.Sum256(content)
+	digest, err := stageResumable(stagingDir, dest, hex.EncodeToString(sum[:]), staticFetcher(content))
+	if err != nil {
+		t.Fatalf("stageResumable() beklenmedik bir hata döndürdü: %v", err)
+	}
+	if digest != hex.EncodeToString(sum[:]) {
+		t.Errorf("Döndürülen özet hatalı: %s", digest)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "model verisi" {
+		t.Errorf("Hedef dosya içeriği hatalı: '%s' (%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 0 {
+		t.Errorf("Başarılı indirmeden sonra staging dizini boş olmalıydı, %d girdi var", len(entries))
+	}
+}
+
+// TestStageResumable_ChecksumMismatch, özeti eşleşmeyen bir dosyanın hedefe
+// taşınmadığını ve yarım dosya olarak da saklanmadığını test eder.
+func TestStageResumable_ChecksumMismatch(t *testing.T) {
+	root := t.TempDir()
+	stagingDir := filepath.Join(root, stagingSubdir)
+	dest := filepath.Join(root, modelsSubdir, "model-v1.bin")
+	wrong := strings.Repeat("0", 64)
+
+	_, err := stageResumable(stagingDir, dest, wrong, staticFetcher([]byte("kurcalanmış model")))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("stageResumable() ErrChecksumMismatch döndürmeliydi, alınan: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Özeti eşleşmeyen dosya hedefe taşınmamalıydı")
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 0 {
+		t.Errorf("Özeti eşleşmeyen dosya staging'de kalmamalıydı, %d girdi var", len(entries))
+	}
+}
+
+// TestNormalizeSHA256, hex ve base64 özetlerin aynı biçime çevrildiğini test eder.
+func TestNormalizeSHA256(t *testing.T) {
+	sum := sha256.Sum256([]byte("model"))
+	want := hex.EncodeToString(sum[:])
+
+	for _, in := range []string{
+		strings.ToUpper(want),
+		base64.StdEncoding.EncodeToString(sum[:]),
+		want + "  model.bin\n",
+	} {
+		got, err := parseSHA256Sidecar(in)
+		if err != nil || got != want {
+			t.Errorf("'%s' için beklenen %s, alınan %s (%v)", in, want, got, err)
+		}
+	}
+	if _, err := normalizeSHA256("abc"); err == nil {
+		t.Error("Geçersiz özet için hata dönmeliydi")
+	}
+}
+
+// TestCleanStaging, başlangıçta kalan yarım dosyaların silindiğini test eder.
+func TestCleanStaging(t *testing.T) {
+	stagingDir := t.TempDir()
+	// Kaydı olmayan yarım dosya silinmeli.
+	os.WriteFile(filepath.Join(stagingDir, "model-v1.bin.part"), []byte("yarım"), 0o644)
+	// Kaydı olan yarım dosya (devam ettirilebilir) korunmalı.
+	os.WriteFile(filepath.Join(stagingDir, "model-v2.bin.part"), []byte("yarım"), 0o644)
+	os.WriteFile(filepath.Join(stagingDir, "model-v2.bin.part.json"), []byte(`{"etag":"v2","ranges":[{"start":0,"end":5}]}`), 0o644)
+
+	if err := CleanStaging(stagingDir); err != nil {
+		t.Fatalf("CleanStaging() hata döndürdü: %v", err)
+	}
+	entries, _ := os.ReadDir(stagingDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "model-v2.bin.part,model-v2.bin.part.json" {
+		t.Errorf("Sadece devam ettirilebilir indirme kalmalıydı, kalanlar: %v", names)
+	}
+
+	// Olmayan bir dizin hata sayılmamalı (ilk kurulum).
+	if err := CleanStaging(filepath.Join(stagingDir, "yok")); err != nil {
+		t.Errorf("Olmayan dizin için CleanStaging() hata döndürmemeliydi: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AgentState, ajanın yeniden başlatmalar arasında hatırlaması gereken
+// kalıcı durumudur. Diskte JSON olarak saklanır.
+type AgentState struct {
+	ETag            string    `json:"etag"`                     // En son başarıyla deploy edilen modelin ETag'i
+	VersionID       string    `json:"version_id,omitempty"`     // ...ve S3 VersionID'si (versiyonlama kapalıysa boş)
+	Key             string    `json:"key,omitempty"`            // ...ve S3 anahtarı (prefix izleme modunda sürüme göre değişir)
+	ActiveModelPath string    `json:"active_model_path"`        // Sembolik bağın gösterdiği model dosyası
+	PreviousModel   string    `json:"previous_model,omitempty"` // Rollback adayı: bir önceki stabil model (GC silmez)
+	SHA256          string    `json:"sha256,omitempty"`         // Aktif modelin SHA-256 özeti
+	SignedVersion   uint64    `json:"signed_version,omitempty"` // Aktif modelin imzalı sürüm numarası (daha eskisi kabul edilmez)
+	DeployedAt      time.Time `json:"deployed_at"`              // Son başarılı dağıtımın zamanı
+	LastError       string    `json:"last_error"`               // Son döngüde alınan hata (varsa)
+
+	// Quarantine, başarısız olmuş model revizyonlarıdır (VersionID veya ETag -> kayıt).
+	Quarantine map[string]QuarantineEntry `json:"quarantine,omitempty"`
+}
+
+// stateFileName, durum dosyasının state dizini içindeki adıdır.
+const stateFileName = "agent_state.json"
+
+// RealStateStore, StateStore arayüzünün durumu yerel diskte bir JSON
+// dosyasında tutan implementasyonudur.
+type RealStateStore struct {
+	dir string
+}
+
+// NewRealStateStore, durumu 'dir' dizini altında saklayan yeni bir
+// RealStateStore oluşturur. Dizin, ilk kayıtta oluşturulur.
+func NewRealStateStore(dir string) *RealStateStore {
+	return &RealStateStore{dir: dir}
+}
+
+// Load, durum dosyasını okur. Dosya henüz yoksa (ilk kurulum) boş bir
+// durum döndürür; bu bir hata değildir.
+func (s *RealStateStore) Load() (*AgentState, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, stateFileName))
+	if os.IsNotExist(err) {
+		return &AgentState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("durum dosyası okunamadı: %w", err)
+	}
+
+	var state AgentState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("durum dosyası bozuk: %w", err)
+	}
+	return &state, nil
+}
+
+// Save, durumu atomik olarak diske yazar: önce geçici bir dosyaya yazılır,
+// fsync edilir ve ardından asıl dosyanın üzerine 'rename' edilir.
+// Böylece elektrik kesintisinde bile yarım yazılmış bir durum dosyası kalmaz.
+func (s *RealStateStore) Save(state *AgentState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("durum serileştirilemedi: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.dir, stateFileName), data)
+}
+
+// writeFileAtomic, 'data'yı 'path' yoluna atomik olarak yazar.
+// Gerekirse üst dizini oluşturur.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("dizin oluşturulamadı (%s): %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("geçici dosya oluşturulamadı: %w", err)
+	}
+	// Herhangi bir adımda hata olursa geçici dosya geride kalmasın.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("geçici dosyaya yazılamadı: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("geçici dosya fsync edilemedi: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("dosya yerine taşınamadı (%s): %w", path, err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir, bir dizindeki değişikliklerin (rename, create) diske yazılmasını
+// garanti etmek için dizini fsync eder. Bazı platformlar (örn: Windows)
+// dizin fsync'ini desteklemez; bu durumda hata yok sayılır.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRealStateStore_RoundTrip, durumun diske yazılıp geri okunabildiğini test eder.
+func TestRealStateStore_RoundTrip(t *testing.T) {
+	// t.TempDir(), test bitince otomatik silinen geçici bir dizin verir.
+	store := NewRealStateStore(filepath.Join(t.TempDir(), "state"))
+
+	// Henüz kayıt yokken Load boş bir durum döndürmeli, hata değil.
+	empty, err := store.Load()
+	if err != nil {
+		t.Fatalf("İlk Load() hata döndürmemeliydi: %v", err)
+	}
+	if empty.ETag != "" {
+		t.Errorf("İlk durumda ETag boş olmalıydı, ancak '%s' alındı", empty.ETag)
+	}
+
+	deployedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := &AgentState{
+		ETag:            "v2-new-model",
+		ActiveModelPath: "models/model-v2-new-model.bin",
+		DeployedAt:      deployedAt,
+		LastError:       "önceki hata",
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save() hata döndürdü: %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() hata döndürdü: %v", err)
+	}
+	if got.ETag != want.ETag || got.ActiveModelPath != want.ActiveModelPath || got.LastError != want.LastError {
+		t.Errorf("Okunan durum yazılanla aynı değil.\nBeklenen: %+v\nAlınan:   %+v", want, got)
+	}
+	if !got.DeployedAt.Equal(deployedAt) {
+		t.Errorf("DeployedAt '%v' olmalıydı, ancak '%v' oldu", deployedAt, got.DeployedAt)
+	}
+}
+
+// TestRealStateStore_Journal, günlüğe yazılan son kaydın okunabildiğini ve
+// çökme sonrası yarım kalan satırın yok sayıldığını test eder.
+func TestRealStateStore_Journal(t *testing.T) {
+	dir := t.TempDir()
+	store := NewRealStateStore(dir)
+
+	if entry, err := store.LastJournal(); err != nil || entry != nil {
+		t.Fatalf("Boş günlükte LastJournal() (nil, nil) döndürmeliydi, alınan: (%v, %v)", entry, err)
+	}
+
+	store.AppendJournal(JournalEntry{Phase: PhaseDownloaded, ETag: "v2"})
+	store.AppendJournal(JournalEntry{Phase: PhaseLinked, ETag: "v2", PreviousTarget: "models/model-v1.bin"})
+
+	// Çökme simülasyonu: son satır yarım yazılmış.
+	f, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("Günlük dosyası açılamadı: %v", err)
+	}
+	f.WriteString(`{"phase":"relo`)
+	f.Close()
+
+	entry, err := store.LastJournal()
+	if err != nil {
+		t.Fatalf("LastJournal() hata döndürdü: %v", err)
+	}
+	if entry == nil || entry.Phase != PhaseLinked || entry.PreviousTarget != "models/model-v1.bin" {
+		t.Errorf("Son geçerli kayıt 'linked' olmalıydı, alınan: %+v", entry)
+	}
+
+	if err := store.ClearJournal(); err != nil {
+		t.Fatalf("ClearJournal() hata döndürdü: %v", err)
+	}
+	if entry, _ := store.LastJournal(); entry != nil {
+		t.Errorf("Temizlenen günlükte kayıt kalmamalıydı, alınan: %+v", entry)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// ObjectVersion, kaynaktaki bir nesnenin belirli bir revizyonunu tanımlar.
+// Bucket versiyonlama açıksa VersionID doludur ve revizyonu kesin olarak
+// belirler; kapalıysa sadece ETag kullanılabilir.
+type ObjectVersion struct {
+	Key            string
+	VersionID      string
+	ETag           string
+	LastModified   time.Time
+	Size           int64
+	Metadata       map[string]string
+	ChecksumSHA256 string // Kaynağın kendi hesapladığı tam dosya özeti (hex, yoksa boş)
+}
+
+// ID, bu revizyonu tanımlayan kimliği döndürür: VersionID varsa o, yoksa ETag.
+// Karantina ve yerel dosya adları bu kimliğe göre tutulur.
+func (v ObjectVersion) ID() string {
+	if v.VersionID != "" {
+		return v.VersionID
+	}
+	return v.ETag
+}
+
+// ID, günlük kaydındaki revizyonun kimliğini döndürür (bkz. ObjectVersion.ID).
+func (e *JournalEntry) ID() string {
+	if e.VersionID != "" {
+		return e.VersionID
+	}
+	return e.ETag
+}
+
+// isCurrent, uzaktaki revizyonun en son commit edilen revizyonla aynı olup
+// olmadığını döndürür. Anahtar farklıysa (prefix modunda başka bir sürüm veya
+// değiştirilen 's3_key') aynı ETag'e rağmen yeni bir sürümdür. İki tarafta da
+// VersionID varsa o karşılaştırılır; böylece aynı içeriğin yeniden yüklenmesi
+// (aynı ETag) de yeni bir sürüm sayılır.
+func (p *Poller) isCurrent(remote ObjectVersion) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if remote.Key != "" && p.deployedKey != "" && remote.Key != p.deployedKey {
+		return false
+	}
+	if remote.VersionID != "" && p.lastKnownVersionID != "" {
+		return remote.VersionID == p.lastKnownVersionID
+	}
+	return remote.ETag == p.lastKnownETag
+}
+
+// currentVersion, en son commit edilen revizyonun kimliğini döndürür.
+func (p *Poller) currentVersion() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.lastKnownVersionID != "" {
+		return p.lastKnownVersionID
+	}
+	return p.lastKnownETag
+}
+
+// fileSafe, bir revizyon kimliğini dosya adında güvenle kullanılabilir hale getirir.
+// S3 VersionID'leri '/', '+' gibi karakterler içerebilir.
+func fileSafe(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
+}